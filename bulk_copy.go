@@ -0,0 +1,237 @@
+package freetds
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unsafe"
+)
+
+/*
+#include <stdlib.h>
+#include <sybfront.h>
+#include <sybdb.h>
+*/
+import "C"
+
+//BulkCopyOptions controls BulkCopy.
+type BulkCopyOptions struct {
+	//Number of rows in each batch, batch is committed when it is sent.
+	//All rows are sent in a single batch when 0.
+	BatchSize int
+	//Insert values into the identity column instead of generating them.
+	KeepIdentity bool
+	//Check table constraints while inserting, they are ignored by default.
+	CheckConstraints bool
+	//Hold table lock for the duration of the copy.
+	TableLock bool
+}
+
+//BulkCopySource supplies rows to the BulkCopy.
+//Next returns values of the next row, in the order of columns, or io.EOF when there are no more rows.
+type BulkCopySource interface {
+	Next() ([]interface{}, error)
+}
+
+//BulkCopySourceFunc is a function used as BulkCopySource.
+type BulkCopySourceFunc func() ([]interface{}, error)
+
+func (f BulkCopySourceFunc) Next() ([]interface{}, error) {
+	return f()
+}
+
+//BulkCopyRows returns BulkCopySource which reads rows from the slice.
+func BulkCopyRows(rows [][]interface{}) BulkCopySource {
+	i := 0
+	return BulkCopySourceFunc(func() ([]interface{}, error) {
+		if i >= len(rows) {
+			return nil, io.EOF
+		}
+		i++
+		return rows[i-1], nil
+	})
+}
+
+//Bulk copy column definition.
+type bulkCopyColumn struct {
+	name    string
+	ordinal int
+	typ     int
+}
+
+//BulkCopy inserts rows from source into the table using FreeTDS bulk copy interface.
+//Columns are names of the table columns in the order of row values, all table columns when empty.
+//Values are converted in the same way as ExecSp params, nil is inserted as NULL.
+//Returns number of rows copied, also on failure: rows of the completed batches,
+//and rows which bcp_done committed when the copy was ended.
+//Bulk copy must be enabled in the login with bulk_copy=true connection string option.
+//
+//Example:
+//  rows := freetds.BulkCopyRows([][]interface{}{{1, "one"}, {2, "two"}})
+//  n, err := conn.BulkCopy("dbo.numbers", []string{"id", "name"}, rows, freetds.BulkCopyOptions{BatchSize: 10000})
+func (conn *Conn) BulkCopy(table string, columns []string, source BulkCopySource, options BulkCopyOptions) (int64, error) {
+	if !conn.bulkCopy {
+		return 0, errors.New("bulk copy is not enabled, set bulk_copy=true in the connection string")
+	}
+	if conn.isDead() || conn.isMirrorSlave() {
+		if err := conn.reconnect(); err != nil {
+			return 0, err
+		}
+	}
+	cols, err := conn.bulkCopyColumns(table, columns)
+	if err != nil {
+		return 0, err
+	}
+	conn.clearMessages()
+
+	ctable := C.CString(table)
+	defer C.free(unsafe.Pointer(ctable))
+	if C.bcp_init(conn.dbproc, ctable, nil, nil, C.DB_IN) == C.FAIL {
+		return 0, conn.raiseError("bcp_init failed")
+	}
+	var copied, inBatch int64
+	if err := conn.bulkCopyOptions(options); err != nil {
+		return conn.bulkCopyDone(copied), err
+	}
+	for _, col := range cols {
+		if C.bcp_bind(conn.dbproc, nil, 0, -1, nil, 0, C.int(col.typ), C.int(col.ordinal)) == C.FAIL {
+			err := conn.raiseError(fmt.Sprintf("bcp_bind failed for column %s", col.name))
+			return conn.bulkCopyDone(copied), err
+		}
+	}
+
+	for {
+		row, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = conn.bulkCopySendRow(cols, row)
+		}
+		if err != nil {
+			return conn.bulkCopyDone(copied), err
+		}
+		inBatch++
+		if options.BatchSize > 0 && inBatch == int64(options.BatchSize) {
+			n := C.bcp_batch(conn.dbproc)
+			if n == -1 {
+				err := conn.raiseError("bcp_batch failed")
+				return conn.bulkCopyDone(copied), err
+			}
+			copied += int64(n)
+			inBatch = 0
+		}
+	}
+	n := C.bcp_done(conn.dbproc)
+	if n == -1 {
+		return copied, conn.raiseError("bcp_done failed")
+	}
+	return copied + int64(n), nil
+}
+
+//Ends the failed copy, adds rows committed by bcp_done to copied.
+func (conn *Conn) bulkCopyDone(copied int64) int64 {
+	if n := C.bcp_done(conn.dbproc); n > 0 {
+		copied += int64(n)
+	}
+	return copied
+}
+
+//Finds ordinals and types of the columns in the table.
+func (conn *Conn) bulkCopyColumns(table string, columns []string) ([]*bulkCopyColumn, error) {
+	results, err := conn.exec(fmt.Sprintf("select top 0 * from %s", quoteName(table)))
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+	tableColumns := results[0].Columns
+	if len(columns) == 0 {
+		for _, c := range tableColumns {
+			columns = append(columns, c.Name)
+		}
+	}
+	cols := make([]*bulkCopyColumn, len(columns))
+	for i, name := range columns {
+		for j, c := range tableColumns {
+			if strings.EqualFold(c.Name, name) {
+				cols[i] = &bulkCopyColumn{name: c.Name, ordinal: j + 1, typ: c.DbType}
+				break
+			}
+		}
+		if cols[i] == nil {
+			return nil, fmt.Errorf("column %s not found in table %s", name, table)
+		}
+	}
+	return cols, nil
+}
+
+func (conn *Conn) bulkCopyOptions(options BulkCopyOptions) error {
+	if options.KeepIdentity {
+		if C.bcp_control(conn.dbproc, C.BCPKEEPIDENTITY, 1) == C.FAIL {
+			return conn.raiseError("bcp_control failed")
+		}
+	}
+	hints := make([]string, 0)
+	if options.TableLock {
+		hints = append(hints, "TABLOCK")
+	}
+	if options.CheckConstraints {
+		hints = append(hints, "CHECK_CONSTRAINTS")
+	}
+	if len(hints) > 0 {
+		hint := strings.Join(hints, ", ")
+		chint := C.CString(hint)
+		defer C.free(unsafe.Pointer(chint))
+		if C.bcp_options(conn.dbproc, C.BCPHINTS, (*C.BYTE)(unsafe.Pointer(chint)), C.int(len(hint))) == C.FAIL {
+			return conn.raiseError("bcp_options failed")
+		}
+	}
+	return nil
+}
+
+//Sets column data pointers to the row values and sends row.
+//Data is copied to C memory because FreeTDS holds pointers until the row is sent.
+func (conn *Conn) bulkCopySendRow(cols []*bulkCopyColumn, row []interface{}) error {
+	if len(row) != len(cols) {
+		return fmt.Errorf("row has %d values, expecting %d", len(row), len(cols))
+	}
+	buffers := make([]unsafe.Pointer, 0, len(cols))
+	defer func() {
+		for _, b := range buffers {
+			C.free(b)
+		}
+	}()
+	for i, col := range cols {
+		var ptr *C.BYTE
+		datalen := 0
+		if row[i] != nil {
+			data, _, err := typeToSqlBuf(col.typ, row[i], conn.freetdsVersionGte095)
+			if err != nil {
+				return fmt.Errorf("column %s: %s", col.name, err)
+			}
+			switch col.typ {
+			case SYBIMAGE, SYBVARBINARY, SYBBINARY, XSYBVARBINARY:
+				//typeToSqlBuf adds terminating null for binary data
+				data = data[:len(data)-1]
+			}
+			datalen = len(data)
+			if datalen > 0 {
+				b := C.CBytes(data)
+				buffers = append(buffers, b)
+				ptr = (*C.BYTE)(b)
+			}
+		}
+		//zero length is NULL
+		if C.bcp_colptr(conn.dbproc, ptr, C.int(col.ordinal)) == C.FAIL ||
+			C.bcp_collen(conn.dbproc, C.DBINT(datalen), C.int(col.ordinal)) == C.FAIL {
+			return conn.raiseError(fmt.Sprintf("bcp_colptr failed for column %s", col.name))
+		}
+	}
+	if C.bcp_sendrow(conn.dbproc) == C.FAIL {
+		return conn.raiseError("bcp_sendrow failed")
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package freetds
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkCopyRows(t *testing.T) {
+	source := BulkCopyRows([][]interface{}{{1, "one"}, {2, "two"}})
+	row, err := source.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, "one"}, row)
+	row, err = source.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{2, "two"}, row)
+	_, err = source.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestBulkCopyNotEnabled(t *testing.T) {
+	conn := &Conn{}
+	_, err := conn.BulkCopy("test_bulk_copy", nil, BulkCopyRows(nil), BulkCopyOptions{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "bulk_copy=true")
+}
+
+func TestBulkCopy(t *testing.T) {
+	conn, err := NewConn(testDbConnStr(1) + ";bulk_copy=true")
+	if err != nil || conn == nil {
+		t.Skip("can't connect to the test database")
+	}
+	defer conn.Close()
+	if conn.sybaseMode() || conn.sybaseMode125() {
+		t.Skip("test table uses Sql Server types")
+	}
+	_, err = conn.Exec(`
+	if exists(select * from sys.tables where name = 'test_bulk_copy')
+	  drop table test_bulk_copy`)
+	assert.Nil(t, err)
+	_, err = conn.Exec(`
+	create table test_bulk_copy (
+	  id int identity(1, 1) not null,
+	  name nvarchar(50) null,
+	  amount decimal(18, 4) null,
+	  created datetime null,
+	  data varbinary(10) null
+	)`)
+	assert.Nil(t, err)
+
+	amount, _ := ParseDecimal("1234.5678")
+	created := time.Date(2014, 1, 5, 23, 24, 0, 0, time.Local)
+	i := 0
+	source := BulkCopySourceFunc(func() ([]interface{}, error) {
+		if i == 2500 {
+			return nil, io.EOF
+		}
+		i++
+		return []interface{}{"išo medo u dućan", amount, created, []byte{1, 2, 3}}, nil
+	})
+	n, err := conn.BulkCopy("test_bulk_copy", []string{"name", "amount", "created", "data"}, source,
+		BulkCopyOptions{BatchSize: 1000, TableLock: true})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2500, n)
+
+	rst, err := conn.Exec("select count(*), max(name), max(amount), max(created) from test_bulk_copy")
+	assert.Nil(t, err)
+	rst[0].Next()
+	var cnt int
+	var name string
+	var amount2 Decimal
+	var created2 time.Time
+	assert.Nil(t, rst[0].Scan(&cnt, &name, &amount2, &created2))
+	assert.Equal(t, 2500, cnt)
+	assert.Equal(t, "išo medo u dućan", name)
+	assert.Equal(t, "1234.5678", amount2.String())
+	assert.True(t, created.Equal(created2))
+
+	_, err = conn.BulkCopy("test_bulk_copy", []string{"id", "name"},
+		BulkCopyRows([][]interface{}{{10000, nil}}), BulkCopyOptions{KeepIdentity: true})
+	assert.Nil(t, err)
+	val, err := conn.SelectValue("select count(*) from test_bulk_copy where id = 10000 and name is null")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, val)
+
+	_, err = conn.BulkCopy("test_bulk_copy", []string{"no_such_column"}, BulkCopyRows(nil), BulkCopyOptions{})
+	assert.NotNil(t, err)
+}
@@ -3,6 +3,7 @@ package freetds
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 )
 
 /*
@@ -17,6 +18,16 @@ var lastErrorMutex sync.Mutex
 var lastMessage string
 var lastMessageMutex sync.Mutex
 
+//errors and messages for the dbproc which is not yet connection (login in progress),
+//guarded by lastErrorMutex
+var loginError Error
+
+func clearLoginError() {
+	lastErrorMutex.Lock()
+	defer lastErrorMutex.Unlock()
+	loginError = Error{}
+}
+
 //export errHandler
 func errHandler(dbprocAddr C.long, severity, dberr, oserr C.int, dberrstr, oserrstr *C.char) C.int {
 	var err string
@@ -30,13 +41,22 @@ func errHandler(dbprocAddr C.long, severity, dberr, oserr C.int, dberrstr, oserr
 	}
 	err += fmt.Sprintf("\n%s\n\n", C.GoString(dberrstr))
 
+	soserr := ""
+	if oserrstr != nil {
+		soserr = C.GoString(oserrstr)
+	}
+
+	conn := getConnection(int64(dbprocAddr))
+
 	lastErrorMutex.Lock()
 	lastError = err
+	if conn == nil {
+		loginError.addDbError(int(dberr), int(oserr), int(severity), soserr)
+	}
 	lastErrorMutex.Unlock()
 
-	conn := getConnection(int64(dbprocAddr))
 	if conn != nil {
-		conn.addError(err)
+		conn.addError(err, int(dberr), int(oserr), int(severity), soserr)
 	}
 
 	//fmt.Printf("err: %s", err)
@@ -50,17 +70,18 @@ func msgHandler(dbprocAddr C.long, msgno C.DBINT, msgstate, severity C.int, msgt
 		return 0
 	}
 
+	ssrvname := C.GoString(srvname)
+	sprocname := C.GoString(procname)
+	smsgtext := C.GoString(msgtext)
+
 	msg := ""
 	if msgno > 0 {
 		msg += fmt.Sprintf("Msg %d, Level %d, State %d\n", msgno, severity, msgstate)
 
-		ssrvname := C.GoString(srvname)
-		sprocname := C.GoString(procname)
-
 		if len(ssrvname) > 0 {
 			msg += fmt.Sprintf("Server '%s', ", ssrvname)
 		}
-		if len(sprocname) < 0 {
+		if len(sprocname) > 0 {
 			msg += fmt.Sprintf("Procedure '%s', ", sprocname)
 		}
 		if line > 0 {
@@ -69,7 +90,17 @@ func msgHandler(dbprocAddr C.long, msgno C.DBINT, msgstate, severity C.int, msgt
 
 		msg += fmt.Sprintf("\n\t")
 	}
-	msg += fmt.Sprintf("%s\n", C.GoString(msgtext))
+	msg += fmt.Sprintf("%s\n", smsgtext)
+
+	sm := ServerMessage{
+		Number:    int(msgno),
+		Severity:  int(severity),
+		State:     int(msgstate),
+		Server:    ssrvname,
+		Procedure: sprocname,
+		Line:      int(line),
+		Text:      smsgtext,
+	}
 
 	lastMessageMutex.Lock()
 	lastMessage = msg
@@ -78,8 +109,22 @@ func msgHandler(dbprocAddr C.long, msgno C.DBINT, msgstate, severity C.int, msgt
 	conn := getConnection(int64(dbprocAddr))
 	if conn != nil {
 		conn.addMessage(msg, int(msgno))
+		conn.addServerMessage(sm)
+	} else {
+		lastErrorMutex.Lock()
+		loginError.addMessage(sm)
+		lastErrorMutex.Unlock()
 	}
 
 	//fmt.Printf("msg: %s", msg)
 	return 0
 }
+
+//export chkIntrHandler
+func chkIntrHandler(dbprocAddr C.long) C.int {
+	conn := getConnection(int64(dbprocAddr))
+	if conn != nil && atomic.LoadInt32(&conn.interrupted) == 1 {
+		return C.TRUE
+	}
+	return C.FALSE
+}
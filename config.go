@@ -0,0 +1,277 @@
+package freetds
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//Config holds connection options, the same as in the connection string.
+//Get it from ParseDSN or fill it directly, zero values mean defaults.
+//
+//Example:
+//  cfg := &freetds.Config{Host: "myServerA", Database: "myDataBase", User: "myUsername", Password: "myPassword"}
+//  conn, err := freetds.NewConnFromConfig(cfg)
+type Config struct {
+	Host string
+	Port int
+	//Named instance, used instead of Port which is ignored when both are set.
+	Instance string
+	Database string
+	User     string
+	Password string
+	//Failover partner, mirror server.
+	MirrorHost string
+	//Compatibility mode: sybase or sybase_12_5.
+	Compatibility string
+	//Max number of connections in the pool, default 100.
+	MaxPoolSize int
+	//Lock timeout in milliseconds, set on the connection after login.
+	LockTimeout int
+	//Declaration of ExecuteSql string and binary params sizes: exact, bucket or schema.
+	ParamSizes string
+	//Seconds to wait for login, default 10.
+	LoginTimeout int
+	//Seconds to wait for query results, no timeout by default.
+	QueryTimeout int
+	PacketSize   int
+	TDSVersion   string
+	AppName      string
+	Workstation  string
+	//Client charset, default UTF-8.
+	Charset string
+	//Encryption mode: off, request or require.
+	Encryption string
+	//Enables bulk copy in the login, needed by BulkCopy.
+	BulkCopy bool
+	//Supplies user and password on every login instead of User and Password.
+	//It is not part of the connection string.
+	CredentialProvider CredentialProvider
+}
+
+//ParseDSN parses connection string, key=value pairs or url, into Config.
+//It is lenient as NewConn, unknown options and malformed values are ignored.
+func ParseDSN(dsn string) (*Config, error) {
+	crd, _, err := parseCredentials(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return crd.config(), nil
+}
+
+//ParseDSNStrict parses connection string into Config and validates it.
+//Returns *ConfigError listing all unknown options, malformed values,
+//options set more than once with different values, conflicting options and missing host or user.
+func ParseDSNStrict(dsn string) (*Config, error) {
+	crd, problems, err := parseCredentials(dsn)
+	if err != nil {
+		return nil, err
+	}
+	cfg := crd.config()
+	problems = append(problems, cfg.problems()...)
+	if len(problems) > 0 {
+		return nil, &ConfigError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+//ConfigError lists problems found in the connection string or Config.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid connection config: " + strings.Join(e.Problems, "; ")
+}
+
+//Allowed values of the options, empty is default.
+var (
+	compatibilityModes = []string{"", SYBASE, SYBASE_12_5}
+	paramSizesModes    = []string{"", paramSizesExact, paramSizesBucket, paramSizesSchema}
+	encryptionModes    = []string{"", "off", "request", "require"}
+	tdsVersions        = []string{"", "4.2", "5.0", "7.0", "7.1", "7.2", "7.3", "7.4"}
+)
+
+//Validate checks that host and user (or credential provider) are set, and values of the options are valid.
+//Returns *ConfigError listing all problems.
+func (cfg *Config) Validate() error {
+	if problems := cfg.problems(); len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+func (cfg *Config) problems() []string {
+	problems := make([]string, 0)
+	if cfg.Host == "" {
+		problems = append(problems, "missing host")
+	}
+	if cfg.User == "" && cfg.CredentialProvider == nil {
+		problems = append(problems, "missing user")
+	}
+	if cfg.Port != 0 && cfg.Instance != "" {
+		problems = append(problems, "conflicting options port and instance, use one of them")
+	}
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("invalid port %d", cfg.Port))
+	}
+	for _, o := range []struct {
+		name  string
+		value int
+	}{
+		{"max_pool_size", cfg.MaxPoolSize},
+		{"login_timeout", cfg.LoginTimeout},
+		{"query_timeout", cfg.QueryTimeout},
+		{"packet_size", cfg.PacketSize},
+	} {
+		if o.value < 0 {
+			problems = append(problems, fmt.Sprintf("negative %s %d", o.name, o.value))
+		}
+	}
+	for _, o := range []struct {
+		name, value string
+		allowed     []string
+	}{
+		{"compatibility", strings.ToLower(cfg.Compatibility), compatibilityModes},
+		{"param_sizes", strings.ToLower(cfg.ParamSizes), paramSizesModes},
+		{"encrypt", strings.ToLower(cfg.Encryption), encryptionModes},
+		{"tds_version", cfg.TDSVersion, tdsVersions},
+	} {
+		if !contains(o.allowed, o.value) {
+			problems = append(problems, fmt.Sprintf("invalid %s %q, expecting one of: %s", o.name, o.value, strings.Join(o.allowed[1:], ", ")))
+		}
+	}
+	return problems
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (crd *credentials) config() *Config {
+	return &Config{
+		Host:          crd.host,
+		Port:          crd.port,
+		Instance:      crd.instance,
+		Database:      crd.database,
+		User:          crd.user,
+		Password:      crd.pwd,
+		MirrorHost:    crd.mirrorHost,
+		Compatibility: crd.compatibility,
+		MaxPoolSize:   crd.maxPoolSize,
+		LockTimeout:   crd.lockTimeout,
+		ParamSizes:    crd.paramSizes,
+		LoginTimeout:  crd.loginTimeout,
+		QueryTimeout:  crd.queryTimeout,
+		PacketSize:    crd.packetSize,
+		TDSVersion:    crd.tdsVersion,
+		AppName:       crd.appName,
+		Workstation:   crd.workstation,
+		Charset:       crd.charset,
+		Encryption:    crd.encryption,
+		BulkCopy:      crd.bulkCopy,
+
+		CredentialProvider: crd.provider,
+	}
+}
+
+func (cfg *Config) credentials() *credentials {
+	crd := &credentials{
+		host:          cfg.Host,
+		port:          cfg.Port,
+		instance:      cfg.Instance,
+		database:      cfg.Database,
+		user:          cfg.User,
+		pwd:           cfg.Password,
+		mirrorHost:    cfg.MirrorHost,
+		compatibility: strings.ToLower(cfg.Compatibility),
+		maxPoolSize:   cfg.MaxPoolSize,
+		lockTimeout:   cfg.LockTimeout,
+		paramSizes:    strings.ToLower(cfg.ParamSizes),
+		loginTimeout:  cfg.LoginTimeout,
+		queryTimeout:  cfg.QueryTimeout,
+		packetSize:    cfg.PacketSize,
+		tdsVersion:    cfg.TDSVersion,
+		appName:       cfg.AppName,
+		workstation:   cfg.Workstation,
+		charset:       cfg.Charset,
+		encryption:    strings.ToLower(cfg.Encryption),
+		bulkCopy:      cfg.BulkCopy,
+		provider:      cfg.CredentialProvider,
+	}
+	if crd.maxPoolSize <= 0 {
+		crd.maxPoolSize = defaultMaxPoolSize
+	}
+	return crd
+}
+
+//FormatDSN returns connection string with all options which are set.
+//ParseDSN of the result returns the same Config, except the credential provider.
+func (cfg *Config) FormatDSN() string {
+	options := []struct {
+		key, value string
+	}{
+		{"host", cfg.Host},
+		{"port", formatInt(cfg.Port)},
+		{"instance", cfg.Instance},
+		{"database", cfg.Database},
+		{"user", cfg.User},
+		{"pwd", cfg.Password},
+		{"mirror", cfg.MirrorHost},
+		{"compatibility", cfg.Compatibility},
+		{"lock_timeout", formatInt(cfg.LockTimeout)},
+		{"param_sizes", cfg.ParamSizes},
+		{"login_timeout", formatInt(cfg.LoginTimeout)},
+		{"query_timeout", formatInt(cfg.QueryTimeout)},
+		{"packet_size", formatInt(cfg.PacketSize)},
+		{"tds_version", cfg.TDSVersion},
+		{"app_name", cfg.AppName},
+		{"workstation_id", cfg.Workstation},
+		{"charset", cfg.Charset},
+		{"encrypt", cfg.Encryption},
+	}
+	if cfg.BulkCopy {
+		options = append(options, struct{ key, value string }{"bulk_copy", "true"})
+	}
+	if cfg.MaxPoolSize > 0 && cfg.MaxPoolSize != defaultMaxPoolSize {
+		options = append(options, struct{ key, value string }{"max_pool_size", formatInt(cfg.MaxPoolSize)})
+	}
+	pairs := make([]string, 0, len(options))
+	for _, o := range options {
+		if o.value != "" {
+			pairs = append(pairs, o.key+"="+quoteConnStrValue(o.value))
+		}
+	}
+	return strings.Join(pairs, ";")
+}
+
+//String returns connection string with the password redacted, safe for logging.
+func (cfg *Config) String() string {
+	redacted := *cfg
+	if redacted.Password != "" {
+		redacted.Password = "*****"
+	}
+	return redacted.FormatDSN()
+}
+
+//Empty string for zero, so the option is omitted.
+func formatInt(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+//Quotes value which would otherwise be split or unquoted by the connection string parser.
+func quoteConnStrValue(value string) string {
+	trimmed := strings.TrimLeft(value, " ")
+	if !strings.Contains(value, ";") && (trimmed == "" || !strings.ContainsAny(trimmed[:1], `'"{`)) {
+		return value
+	}
+	return `"` + strings.Replace(value, `"`, `""`, -1) + `"`
+}
@@ -38,21 +38,82 @@ import (
    return msgHandler((long)dbproc, msgno, msgstate, severity, msgtext, srvname, procname, line);
  }
 
- static void my_dblogin(LOGINREC* login, char* username, char* password) {
-  dbsetlogintime(10);
+ static int chk_intr(void *dbproc)
+ {
+   extern int chkIntrHandler(long dbprocAddr);
+   return chkIntrHandler((long)dbproc);
+ }
+
+ static int hndl_intr(void *dbproc)
+ {
+   return INT_CANCEL;
+ }
+
+ static void my_setinterrupt(DBPROCESS * dbproc) {
+  dbsetinterrupt(dbproc, chk_intr, hndl_intr);
+ }
+
+ static void my_dblogin(LOGINREC* login, char* username, char* password, char* charset, int logintime, int bcp) {
+  //login time is process global, logins are serialized by getDbProcMutex so each dbopen uses its own value
+  dbsetlogintime(logintime);
   dberrhandle(err_handler);
   dbmsghandle(msg_handler);
   DBSETLUSER(login, username);
   DBSETLPWD(login, password);
-  dbsetlname(login, "UTF-8", DBSETCHARSET);
+  DBSETLCHARSET(login, charset);
+  //enable bulk copy, see BulkCopy
+  if (bcp) BCP_SETL(login, TRUE);
  }
 
  static void my_dblogin_setdb(LOGINREC* login, char* dbname) {
   DBSETLDBNAME(login, dbname);
  }
 
- static void my_setlversion(LOGINREC* login) {
-  dbsetlversion(login, DBVERSION_72);
+ static int my_setlversion(LOGINREC* login, char* version) {
+  if (strcmp(version, "") == 0) {
+ #ifdef DBVERSION_73
+   //7.3 is needed for native date, time, datetime2 and datetimeoffset
+   return dbsetlversion(login, DBVERSION_73);
+ #else
+   return dbsetlversion(login, DBVERSION_72);
+ #endif
+  }
+  if (strcmp(version, "4.2") == 0) return dbsetlversion(login, DBVERSION_42);
+  if (strcmp(version, "5.0") == 0) return dbsetlversion(login, DBVERSION_100);
+  if (strcmp(version, "7.0") == 0) return dbsetlversion(login, DBVERSION_70);
+  if (strcmp(version, "7.1") == 0) return dbsetlversion(login, DBVERSION_71);
+  if (strcmp(version, "7.2") == 0) return dbsetlversion(login, DBVERSION_72);
+ #ifdef DBVERSION_73
+  if (strcmp(version, "7.3") == 0) return dbsetlversion(login, DBVERSION_73);
+ #endif
+ #ifdef DBVERSION_74
+  if (strcmp(version, "7.4") == 0) return dbsetlversion(login, DBVERSION_74);
+ #endif
+  return FAIL;
+ }
+
+ static int my_setlport(LOGINREC* login, int port) {
+ #ifdef DBSETPORT
+  return dbsetlshort(login, port, DBSETPORT);
+ #else
+  return FAIL;
+ #endif
+ }
+
+ static int my_setlencryption(LOGINREC* login, char* mode) {
+ #ifdef DBSETENCRYPTION
+  return dbsetlname(login, mode, DBSETENCRYPTION);
+ #else
+  return FAIL;
+ #endif
+ }
+
+ static int my_settime(DBPROCESS* dbproc, char* seconds) {
+ #ifdef DBSETTIME
+  return dbsetopt(dbproc, DBSETTIME, seconds, 0);
+ #else
+  return FAIL;
+ #endif
  }
 
  static long dbproc_addr(DBPROCESS * dbproc) {
@@ -94,12 +155,24 @@ type Conn struct {
 
 	messageNums  map[int]int
 	messageMutex sync.RWMutex
+	sqlError     Error
 
 	currentResult   *Result
 	expiresFromPool time.Time
 	belongsToPool   *ConnPool
 
 	spParamsCache *ParamsCache
+	//types of ExecuteSql params, see describeParams
+	paramTypes *paramTypesCache
+	//prepared statement handles, see preparedSql
+	prepared *preparedCache
+
+	//set to 1 when context of the running call is done, see withContext
+	interrupted int32
+
+	//retry policy for ExecSp, used only outside of transaction started by Begin
+	spRetryPolicy *RetryPolicy
+	inTransaction bool
 
 	credentials
 	freetdsVersionGte095 bool
@@ -121,11 +194,21 @@ func (conn *Conn) addMessage(msg string, msgno int) {
 	conn.messageNums[msgno] = i + 1
 }
 
-func (conn *Conn) addError(err string) {
+func (conn *Conn) addServerMessage(m ServerMessage) {
+	conn.messageMutex.Lock()
+	defer conn.messageMutex.Unlock()
+	conn.sqlError.addMessage(m)
+}
+
+func (conn *Conn) addError(err string, dberr, oserr, severity int, oserrText string) {
+	conn.messageMutex.Lock()
+	defer conn.messageMutex.Unlock()
+
 	if len(conn.Error) > 0 {
 		conn.Error += "\n"
 	}
 	conn.Error += err
+	conn.sqlError.addDbError(dberr, oserr, severity, oserrText)
 }
 
 //Connect to the database with connection string, returns new connection or error.
@@ -137,9 +220,15 @@ func NewConn(connStr string) (*Conn, error) {
 	return connectWithCredentials(NewCredentials(connStr))
 }
 
+//Connect to the database with options from the config, returns new connection or error.
+func NewConnFromConfig(cfg *Config) (*Conn, error) {
+	return connectWithCredentials(cfg.credentials())
+}
+
 func connectWithCredentials(crd *credentials) (*Conn, error) {
 	conn := &Conn{
 		spParamsCache: NewParamsCache(),
+		paramTypes:    newParamTypesCache(),
 		credentials:   *crd,
 		messageNums:   make(map[int]int),
 	}
@@ -154,13 +243,18 @@ func (conn *Conn) connect() (*Conn, error) {
 	//log.Printf("freetds connecting to %s@%s.%s", conn.user, conn.host, conn.database)
 	conn.close()
 	conn.clearMessages()
-	dbproc, err := conn.getDbProc()
+	dbproc, err := conn.getDbProc(false)
+	if err != nil && conn.provider != nil && IsLoginFailed(err) {
+		//password might be rotated, try once more with fresh credentials
+		dbproc, err = conn.getDbProc(true)
+	}
 	if err != nil {
 		return nil, err
 	}
 	conn.dbproc = dbproc
 	conn.addr = int64(C.dbproc_addr(dbproc))
 	addConnection(conn)
+	C.my_setinterrupt(dbproc)
 	if err := conn.setDefaults(); err != nil {
 		conn.close()
 		return nil, err
@@ -181,6 +275,8 @@ func (conn *Conn) Close() {
 
 func (conn *Conn) close() {
 	deleteConnection(conn)
+	//handles are released by the server when the session ends
+	conn.prepared = nil
 	if conn.dbproc != nil {
 		C.dbclose(conn.dbproc)
 		C.dbexit()
@@ -192,9 +288,15 @@ func (conn *Conn) close() {
 //ensure only one getDbProc at a time
 var getDbProcMutex = &sync.Mutex{}
 
-func (conn *Conn) getDbProc() (*C.DBPROCESS, error) {
+//Refresh is passed to the credential provider.
+func (conn *Conn) getDbProc(refresh bool) (*C.DBPROCESS, error) {
+	user, pwd, err := conn.loginCredentials(refresh)
+	if err != nil {
+		return nil, err
+	}
 	getDbProcMutex.Lock()
 	defer getDbProcMutex.Unlock()
+	clearLoginError()
 	erc := C.dbinit()
 	if erc == C.FAIL {
 		return nil, errors.New("cannot allocate an array of TDS_MAX_CONN TDSSOCKET pointers")
@@ -205,11 +307,20 @@ func (conn *Conn) getDbProc() (*C.DBPROCESS, error) {
 	}
 	defer C.dbloginfree(login)
 
-	cuser := C.CString(conn.user)
+	cuser := C.CString(user)
 	defer C.free(unsafe.Pointer(cuser))
-	cpwd := C.CString(conn.pwd)
+	cpwd := C.CString(pwd)
 	defer C.free(unsafe.Pointer(cpwd))
-	C.my_dblogin(login, cuser, cpwd)
+	ccharset := C.CString(conn.charset())
+	defer C.free(unsafe.Pointer(ccharset))
+	bcp := 0
+	if conn.bulkCopy {
+		bcp = 1
+	}
+	C.my_dblogin(login, cuser, cpwd, ccharset, C.int(conn.loginTimeout()), C.int(bcp))
+	if err := conn.setLoginOptions(login); err != nil {
+		return nil, err
+	}
 
 	// If a database name is specified in the connection string,
 	// add the DB name to the login packet.
@@ -224,20 +335,97 @@ func (conn *Conn) getDbProc() (*C.DBPROCESS, error) {
 	// Added for Sybase compatibility mode
 	// Version cannot be set to 7.2
 	// Allowing version to be set inside freetds
-	if !conn.sybaseMode() && !conn.sybaseMode125() {
-		C.my_setlversion(login)
+	if (!conn.sybaseMode() && !conn.sybaseMode125()) || conn.tdsVersion != "" {
+		cversion := C.CString(conn.tdsVersion)
+		defer C.free(unsafe.Pointer(cversion))
+		if C.my_setlversion(login, cversion) == C.FAIL {
+			return nil, fmt.Errorf("unsupported tds version %s", conn.tdsVersion)
+		}
 	}
 
-	chost := C.CString(conn.host)
+	chost := C.CString(conn.serverName(login))
 	defer C.free(unsafe.Pointer(chost))
 	dbproc := C.dbopen(login, chost)
 	if dbproc == nil {
 		return nil, dbProcError("dbopen error")
 	}
+	if conn.queryTimeout > 0 {
+		cseconds := C.CString(strconv.Itoa(conn.queryTimeout))
+		defer C.free(unsafe.Pointer(cseconds))
+		if C.my_settime(dbproc, cseconds) == C.FAIL {
+			C.dbclose(dbproc)
+			return nil, errors.New("can't set query timeout")
+		}
+	}
 	conn.readFreeTdsVersion()
 	return dbproc, nil
 }
 
+//Default login timeout in seconds.
+//Db-lib login timeout is process global, it is set before each login while
+//getDbProcMutex is held and stays set until the next login.
+const defaultLoginTimeout = 10
+
+func (conn *Conn) loginTimeout() int {
+	if conn.credentials.loginTimeout > 0 {
+		return conn.credentials.loginTimeout
+	}
+	return defaultLoginTimeout
+}
+
+//Client charset, strings are converted from UTF-8 so it should be changed only for old servers.
+func (conn *Conn) charset() string {
+	if conn.credentials.charset != "" {
+		return conn.credentials.charset
+	}
+	return "UTF-8"
+}
+
+//Sets optional login options from the connection string.
+func (conn *Conn) setLoginOptions(login *C.LOGINREC) error {
+	setName := func(value string, set func(*C.char) C.RETCODE, name string) error {
+		if value == "" {
+			return nil
+		}
+		cvalue := C.CString(value)
+		defer C.free(unsafe.Pointer(cvalue))
+		if set(cvalue) == C.FAIL {
+			return fmt.Errorf("can't set %s", name)
+		}
+		return nil
+	}
+	if err := setName(conn.appName, func(v *C.char) C.RETCODE { return C.dbsetlname(login, v, C.DBSETAPP) }, "application name"); err != nil {
+		return err
+	}
+	if err := setName(conn.workstation, func(v *C.char) C.RETCODE { return C.dbsetlname(login, v, C.DBSETHOST) }, "workstation"); err != nil {
+		return err
+	}
+	if err := setName(conn.encryption, func(v *C.char) C.RETCODE { return C.my_setlencryption(login, v) }, "encryption"); err != nil {
+		return err
+	}
+	if conn.packetSize > 0 {
+		if C.dbsetllong(login, C.long(conn.packetSize), C.DBSETPACKET) == C.FAIL {
+			return errors.New("can't set packet size")
+		}
+	}
+	return nil
+}
+
+//Name of the server passed to dbopen.
+//Port is set in the login, or added to the name as host:port when FreeTDS doesn't support it.
+//Instance is added as host\instance and used instead of port.
+func (conn *Conn) serverName(login *C.LOGINREC) string {
+	name := conn.host
+	if conn.instance != "" {
+		//port of the instance is resolved by the server browser, port option is not used
+		return name + "\\" + conn.instance
+	}
+	if conn.port > 0 && C.my_setlport(login, C.int(conn.port)) == C.FAIL {
+		name = fmt.Sprintf("%s:%d", conn.host, conn.port)
+	}
+	return name
+}
+
 func (conn *Conn) readFreeTdsVersion() {
 	dbVersion := C.GoString(C.dbversion())
 	freeTdsVersion := parseFreeTdsVersion(dbVersion)
@@ -245,7 +433,11 @@ func (conn *Conn) readFreeTdsVersion() {
 }
 
 func dbProcError(msg string) error {
-	return fmt.Errorf("%s\n%s\n%s", msg, lastError, lastMessage)
+	lastErrorMutex.Lock()
+	defer lastErrorMutex.Unlock()
+	lastMessageMutex.Lock()
+	defer lastMessageMutex.Unlock()
+	return loginError.withText(fmt.Sprintf("%s\n%s\n%s", msg, lastError, lastMessage))
 }
 
 //Change database.
@@ -268,6 +460,7 @@ func (conn *Conn) clearMessages() {
 	conn.Error = ""
 	conn.Message = ""
 	conn.messageNums = make(map[int]int)
+	conn.sqlError = Error{}
 }
 
 //Returns the number of occurances of a supplied FreeTDS message number.
@@ -374,21 +567,33 @@ func (conn *Conn) isLive() bool {
 //Begin database transaction.
 func (conn *Conn) Begin() error {
 	_, err := conn.Exec("begin transaction")
+	conn.inTransaction = err == nil
 	return err
 }
 
 //Commit database transaction.
 func (conn *Conn) Commit() error {
 	_, err := conn.Exec("commit transaction")
+	if err == nil {
+		conn.inTransaction = false
+	}
 	return err
 }
 
 //Rollback database transaction.
 func (conn *Conn) Rollback() error {
 	_, err := conn.Exec("if @@trancount > 0 rollback transaction")
+	conn.inTransaction = false
 	return err
 }
 
+//Set retry policy for ExecSp.
+//Stored procedure is not retried inside transaction started with Begin,
+//because failure (e.g. deadlock) rolls back the whole transaction.
+func (conn *Conn) SetRetryPolicy(policy *RetryPolicy) {
+	conn.spRetryPolicy = policy
+}
+
 //Query database and return first column in the first row as result.
 func (conn *Conn) SelectValue(sql string) (interface{}, error) {
 	results, err := conn.Exec(sql)
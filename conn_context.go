@@ -0,0 +1,117 @@
+package freetds
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+)
+
+/*
+#include <sybfront.h>
+#include <sybdb.h>
+*/
+import "C"
+
+//Execute sql query, interrupting it when ctx is canceled or its deadline expires.
+func (conn *Conn) ExecContext(ctx context.Context, sql string) ([]*Result, error) {
+	var results []*Result
+	err := conn.withContext(ctx, func() error {
+		var err error
+		results, err = conn.Exec(sql)
+		return err
+	})
+	return results, err
+}
+
+//Execute sql query with arguments, interrupting it when ctx is done.
+//? in query are arguments placeholders, same as in ExecuteSql.
+func (conn *Conn) ExecuteSqlContext(ctx context.Context, query string, params ...driver.Value) ([]*Result, error) {
+	var results []*Result
+	err := conn.withContext(ctx, func() error {
+		var err error
+		results, err = conn.ExecuteSql(query, params...)
+		return err
+	})
+	return results, err
+}
+
+//Execute stored procedure by name and list of params, interrupting it when ctx is done.
+func (conn *Conn) ExecSpContext(ctx context.Context, spName string, params ...interface{}) (*SpResult, error) {
+	var result *SpResult
+	err := conn.withContext(ctx, func() error {
+		var err error
+		result, err = conn.ExecSp(spName, params...)
+		return err
+	})
+	return result, err
+}
+
+//Query database and return first column in the first row as result, interrupting query when ctx is done.
+func (conn *Conn) SelectValueContext(ctx context.Context, sql string) (interface{}, error) {
+	var value interface{}
+	err := conn.withContext(ctx, func() error {
+		var err error
+		value, err = conn.SelectValue(sql)
+		return err
+	})
+	return value, err
+}
+
+//Runs fn watching ctx.
+//After interruption connection is cleaned with dbcancel, or closed if that fails,
+//so the pool will discard it on release.
+//Result of fn which completed before ctx was done is returned as is,
+//ctx error is reported only when the batch failed with the interrupt (timeout) dberr.
+func (conn *Conn) withContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stop := conn.watch(ctx)
+	err := fn()
+	if stop() && err != nil {
+		conn.cancel()
+		if hasDbErr(err, dbErrTimeout) {
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+//Starts watching ctx.
+//When ctx is done interrupted flag is raised. FreeTDS polls it through
+//chkIntrHandler (about once a second while waiting for the server) and
+//cancels the running batch.
+//Returned function stops watching and reports whether ctx was done in the meantime.
+func (conn *Conn) watch(ctx context.Context) func() bool {
+	atomic.StoreInt32(&conn.interrupted, 0)
+	if ctx.Done() == nil {
+		return func() bool { return false }
+	}
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			atomic.StoreInt32(&conn.interrupted, 1)
+		case <-done:
+		}
+	}()
+	return func() bool {
+		close(done)
+		<-exited
+		return atomic.SwapInt32(&conn.interrupted, 0) == 1
+	}
+}
+
+//Cancels any pending results on the connection.
+//Closes connection if it can't be brought to the clean state.
+func (conn *Conn) cancel() {
+	if conn.isDead() {
+		conn.close()
+		return
+	}
+	if C.dbcancel(conn.dbproc) == C.FAIL || conn.isDead() {
+		conn.close()
+	}
+}
@@ -0,0 +1,70 @@
+package freetds
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecContextTimeout(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := conn.ExecContext(ctx, "waitfor delay '00:00:10'")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	//connection is reusable after cancellation
+	val, err := conn.SelectValue("select 1")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, val)
+}
+
+func TestExecContextCanceledBeforeStart(t *testing.T) {
+	conn := &Conn{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := conn.ExecContext(ctx, "select 1")
+	assert.Equal(t, context.Canceled, err)
+	_, err = conn.ExecSpContext(ctx, "sp_help")
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestWithContextKeepsCompletedResult(t *testing.T) {
+	conn := &Conn{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	//ctx is done after the batch has completed, result is not discarded
+	err := conn.withContext(ctx, func() error {
+		cancel()
+		for atomic.LoadInt32(&conn.interrupted) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, conn.interrupted)
+}
+
+func TestSelectValueContext(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	val, err := conn.SelectValueContext(ctx, "select 1")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, val)
+}
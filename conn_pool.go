@@ -1,6 +1,7 @@
 package freetds
 
 import (
+	"fmt"
 	"sync"
 	"time"
 )
@@ -28,7 +29,7 @@ var poolCleanupInterval = time.Minute
 //  ...
 //  pool.Close()
 type ConnPool struct {
-	connStr       string
+	crd           *credentials
 	maxConn       int
 	pool          []*Conn
 	done          chan bool
@@ -38,6 +39,8 @@ type ConnPool struct {
 	connCount     int
 
 	spParamsCache *ParamsCache
+	paramTypes    *paramTypesCache
+	retryPolicy   *RetryPolicy
 }
 
 //NewCoonPool creates new connection pool.
@@ -53,12 +56,23 @@ type ConnPool struct {
 //   "host=myServerA;database=myDataBase;user=myUsername;pwd=myPassword;max_pool_size=500"
 //   "host=myServerA;database=myDataBase;user=myUsername;pwd=myPassword;mirror=myMirror"
 func NewConnPool(connStr string) (*ConnPool, error) {
+	return newConnPool(NewCredentials(connStr))
+}
+
+//NewConnPoolFromConfig creates new connection pool with options from the config.
+//Max number of connections in the pool is config MaxPoolSize.
+func NewConnPoolFromConfig(cfg *Config) (*ConnPool, error) {
+	return newConnPool(cfg.credentials())
+}
+
+func newConnPool(crd *credentials) (*ConnPool, error) {
 	p := &ConnPool{
-		connStr:       connStr,
+		crd:           crd,
 		pool:          []*Conn{},
 		cleanupTicker: time.NewTicker(poolCleanupInterval),
 		connCount:     0,
 		spParamsCache: NewParamsCache(),
+		paramTypes:    newParamTypesCache(),
 		done:          make(chan bool, 1),
 	}
 	conn, err := p.newConn()
@@ -82,13 +96,14 @@ func NewConnPool(connStr string) (*ConnPool, error) {
 }
 
 func (p *ConnPool) newConn() (*Conn, error) {
-	conn, err := NewConn(p.connStr)
+	conn, err := connectWithCredentials(p.crd)
 	p.poolMutex.Lock()
 	defer p.poolMutex.Unlock()
 	if err == nil {
 		conn.belongsToPool = p
 		//share stored procedure params cache between connections in the pool
 		conn.spParamsCache = p.spParamsCache
+		conn.paramTypes = p.paramTypes
 		p.connCount++
 	}
 	return conn, err
@@ -110,33 +125,67 @@ func (p *ConnPool) Get() (*Conn, error) {
 	return conn, nil
 }
 
+//Set retry policy for Do and DoInTransaction.
+//Should be called before the pool is used. Nil disables retries.
+func (p *ConnPool) SetRetryPolicy(policy *RetryPolicy) {
+	p.retryPolicy = policy
+}
+
 //Get connection from pool and execute handler.
 //Release connection after handler is called.
+//
+//If retry policy is set handler is called again, on the fresh or reconnected connection,
+//while it returns retryable error.
 func (p *ConnPool) Do(handler func(*Conn) error) error {
+	return p.retryPolicy.run(func() error {
+		return p.do(handler)
+	})
+}
+
+func (p *ConnPool) do(handler func(*Conn) error) error {
 	conn, err := p.Get()
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
-	return handler(conn)
+	err = handler(conn)
+	if err != nil && IsMirrorFailover(err) {
+		//switch to the mirror before releasing to the pool
+		conn.reconnect()
+	}
+	return err
 }
 
 //Get new connection from pool, and execute handler in transaction.
 //If handler returns error transaction will be rolled back.
 //Release connection after handerl is called.
+//
+//Errors from begin, commit and rollback are returned.
+//If retry policy is set whole transaction is executed again when it fails with retryable error
+//(e.g. deadlock victim).
 func (p *ConnPool) DoInTransaction(handler func(*Conn) error) error {
 	return p.Do(func(conn *Conn) error {
-		conn.Begin()
-		err := handler(conn)
-		if err != nil {
-			conn.Rollback()
-		} else {
-			conn.Commit()
+		if err := conn.Begin(); err != nil {
+			return err
 		}
-		return err
+		if err := handler(conn); err != nil {
+			return rollback(conn, err)
+		}
+		if err := conn.Commit(); err != nil {
+			return rollback(conn, err)
+		}
+		return nil
 	})
 }
 
+//Rollbacks transaction after err, returns err.
+func rollback(conn *Conn, err error) error {
+	if rerr := conn.Rollback(); rerr != nil {
+		return fmt.Errorf("%w\nrollback failed: %v", err, rerr)
+	}
+	return err
+}
+
 func (p *ConnPool) getPooled() *Conn {
 	p.poolMutex.Lock()
 	defer p.poolMutex.Unlock()
@@ -185,3 +185,31 @@ func TestConnPoolDo(t *testing.T) {
 	assert.Equal(t, 1, len(p.pool))
 	assert.Equal(t, 1, p.connCount)
 }
+
+func TestConnPoolDoInTransactionCommitError(t *testing.T) {
+	p, _ := NewConnPool(testDbConnStr(2))
+	defer p.Close()
+	err := p.DoInTransaction(func(conn *Conn) error {
+		//commit inside handler so the pool commit fails
+		_, err := conn.Exec("commit transaction")
+		return err
+	})
+	assert.NotNil(t, err)
+}
+
+func TestConnPoolDoRetry(t *testing.T) {
+	p, _ := NewConnPool(testDbConnStr(2))
+	defer p.Close()
+	p.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3})
+	calls := 0
+	err := p.DoInTransaction(func(conn *Conn) error {
+		calls++
+		if calls == 1 {
+			return deadlockError()
+		}
+		_, err := conn.Exec("select 1")
+		return err
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+}
@@ -25,7 +25,61 @@ import "C"
 //
 //Example:
 //  conn.ExecSp("sp_help", "authors")
+//
+//If retry policy is set (see SetRetryPolicy) call is retried on retryable errors,
+//unless it is executed in transaction.
+//Transaction started in sql is checked with @@trancount after the failure,
+//so transaction already rolled back by the server (deadlock victim) can't be detected.
 func (conn *Conn) ExecSp(spName string, params ...interface{}) (*SpResult, error) {
+	if conn.spRetryPolicy == nil || conn.inTransaction {
+		return conn.execSp(spName, params...)
+	}
+	policy := *conn.spRetryPolicy
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	policy.Retryable = func(err error) bool {
+		return retryable(err) && !conn.inTransaction && !conn.transactionOpen()
+	}
+	var result *SpResult
+	err := policy.run(func() error {
+		var err error
+		result, err = conn.execSp(spName, params...)
+		return err
+	})
+	return result, err
+}
+
+//Reports whether transaction is open in the session, also when it is
+//started with begin transaction in sql, which is not tracked by Begin.
+//Failed check is reported as open transaction, so the call is not retried.
+//Messages of the previous call are preserved.
+func (conn *Conn) transactionOpen() bool {
+	if conn.isDead() {
+		//session is reconnected before the call, without transaction
+		return false
+	}
+	conn.messageMutex.RLock()
+	errorText, message, messageNums, sqlError := conn.Error, conn.Message, conn.messageNums, conn.sqlError
+	conn.messageMutex.RUnlock()
+	defer func() {
+		conn.messageMutex.Lock()
+		conn.Error, conn.Message, conn.messageNums, conn.sqlError = errorText, message, messageNums, sqlError
+		conn.messageMutex.Unlock()
+	}()
+	results, err := conn.exec("select @@trancount")
+	if err != nil || len(results) == 0 || len(results[0].Rows) != 1 {
+		return true
+	}
+	var trancount int
+	if err := convertAssign(&trancount, results[0].Rows[0][0]); err != nil {
+		return true
+	}
+	return trancount > 0
+}
+
+func (conn *Conn) execSp(spName string, params ...interface{}) (*SpResult, error) {
 	if conn.isDead() || conn.isMirrorSlave() {
 		if err := conn.reconnect(); err != nil {
 			return nil, err
@@ -40,19 +94,24 @@ func (conn *Conn) ExecSp(spName string, params ...interface{}) (*SpResult, error
 	name := C.CString(spName)
 	defer C.free(unsafe.Pointer(name))
 
-	if C.dbrpcinit(conn.dbproc, name, 0) == C.FAIL {
-		return nil, conn.raiseError("dbrpcinit failed")
-	}
-	//input params
 	spParams, err := conn.getSpParams(spName)
 	if err != nil {
 		return nil, err
 	}
+	if hasTVP(params) {
+		return conn.execSpBatch(spName, spParams, params)
+	}
+
+	if C.dbrpcinit(conn.dbproc, name, 0) == C.FAIL {
+		return nil, conn.raiseError("dbrpcinit failed")
+	}
+	//input params
 	for i, spParam := range spParams {
 		//get datavalue for the suplied stored procedure parametar
 		var datavalue *C.BYTE
 		datalen := 0
-		if i < len(params) {
+		send := i < len(params) && !isSpDefault(params[i])
+		if send {
 			param := params[i]
 			if param != nil {
 				data, sqlDatalen, err := typeToSqlBuf(int(spParam.UserTypeId), param, conn.freetdsVersionGte095)
@@ -68,7 +127,7 @@ func (conn *Conn) ExecSp(spName string, params ...interface{}) (*SpResult, error
 			}
 		}
 		//set parametar valus, call dbrpcparam
-		if i < len(params) || spParam.IsOutput {
+		if send || spParam.IsOutput {
 			maxOutputSize := C.DBINT(-1)
 			status := C.BYTE(0)
 			if spParam.IsOutput {
@@ -77,6 +136,9 @@ func (conn *Conn) ExecSp(spName string, params ...interface{}) (*SpResult, error
 				if maxOutputSize == -1 {
 					maxOutputSize = 8000
 				}
+				if isDateTimeAllType(int(spParam.UserTypeId)) {
+					maxOutputSize = dbDateTimeAllSize
+				}
 			}
 			paramname := C.CString(spParam.Name)
 			defer C.free(unsafe.Pointer(paramname))
@@ -117,9 +179,19 @@ func (conn *Conn) ExecSp(spName string, params ...interface{}) (*SpResult, error
 	return result, nil
 }
 
+//Param value which is not sent, so the procedure uses the default value.
+type spDefault struct{}
+
+func isSpDefault(param interface{}) bool {
+	_, ok := param.(spDefault)
+	return ok
+}
+
 func (conn *Conn) raise(err error) error {
 	if len(conn.Error) != 0 {
-		return errors.New(fmt.Sprintf("%s\n%s", conn.Error, conn.Message))
+		conn.messageMutex.RLock()
+		defer conn.messageMutex.RUnlock()
+		return conn.sqlError.withText(fmt.Sprintf("%s\n%s", conn.Error, conn.Message))
 	}
 	return err
 }
@@ -150,6 +222,8 @@ type spParam struct {
 	MaxLength   int16
 	Precision   uint8
 	Scale       uint8
+	TypeName    string
+	IsUserType  bool
 }
 
 //Read stored procedure parameters.
@@ -169,11 +243,7 @@ func (conn *Conn) getSpParams(spName string) ([]*spParam, error) {
 	spParams := make([]*spParam, len(r.Rows))
 	for i := 0; r.Next(); i++ {
 		p := &spParam{}
-		err := r.Scan(&p.Name, &p.ParameterId, &p.UserTypeId, &p.IsOutput, &p.MaxLength, &p.Precision, &p.Scale)
-		//fixme: mapping uniqueidentifier, datetimeoffset, date, time, datetime2 to string
-		if p.UserTypeId == 0x24 || p.UserTypeId == 0x2B || p.UserTypeId == 0x28 || p.UserTypeId == 0x29 || p.UserTypeId == 0x2A {
-			p.UserTypeId = 0x27
-		}
+		err := r.Scan(&p.Name, &p.ParameterId, &p.UserTypeId, &p.IsOutput, &p.MaxLength, &p.Precision, &p.Scale, &p.TypeName, &p.IsUserType)
 		if err != nil {
 			return nil, err
 		}
@@ -185,10 +255,17 @@ func (conn *Conn) getSpParams(spName string) ([]*spParam, error) {
 }
 
 const msSqlGetSpParamsSql string = `
-select name, parameter_id, user_type_id, is_output, max_length, precision, scale
-from sys.all_parameters
-where object_id =  (select object_id from sys.all_objects where object_id = object_id('%s'))
-order by parameter_id
+select p.name, p.parameter_id, p.user_type_id, p.is_output, p.max_length, p.precision, p.scale,
+       type_name = case
+                     when t.is_user_defined = 1 then quotename(schema_name(t.schema_id)) + '.' + quotename(t.name)
+                     else t.name
+                   end,
+       is_user_type = t.is_user_defined
+from sys.all_parameters p
+     join sys.types t
+       on t.user_type_id = p.user_type_id
+where p.object_id =  (select object_id from sys.all_objects where object_id = object_id('%s'))
+order by p.parameter_id
 `
 
 const sybaseAseGetSpParamsSql string = `
@@ -201,7 +278,9 @@ const sybaseAseGetSpParamsSql string = `
                      end,
          max_length = c.length,
          precision = isnull(c.prec,0),
-         scale = isnull(c.scale,0)
+         scale = isnull(c.scale,0),
+         type_name = '',
+         is_user_type = 0
     from sysobjects o
          join syscolumns c
            on c.id = o.id
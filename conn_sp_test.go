@@ -1,6 +1,8 @@
 package freetds
 
 import (
+	"context"
+	"database/sql/driver"
 	"fmt"
 	"strings"
 	"testing"
@@ -449,15 +451,40 @@ func TestNewDateTypesParam(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, rst)
 	rst.Next()
-	var op1, op2, op3, op4 string
+	var op1, op2, op3, op4 time.Time
 	var dt time.Time
 	err = rst.Scan(&dt, &op1, &op2, &op3, &op4)
 	assert.Nil(t, err)
 	assert.Equal(t, "Wed Dec 10 12:32:10 2025", dt.Format(time.ANSIC)) //ANSIC format makes test independent of the time zone in which the test is run
-	assert.Equal(t, "2025-12-10 12:32:10.1237000 +01:00", op1)
-	assert.Equal(t, "2025-12-10", op2)
-	assert.Equal(t, "12:30:00.0000000", op3)
-	assert.Equal(t, "2025-12-10 12:32:10.0000000", op4)
+	assert.Equal(t, "2025-12-10 12:32:10.1237 +01:00", op1.Format("2006-01-02 15:04:05.9999999 -07:00"))
+	assert.Equal(t, "2025-12-10", op2.Format("2006-01-02"))
+	assert.Equal(t, "12:30:00", op3.Format("15:04:05"))
+	assert.Equal(t, "2025-12-10 12:32:10", op4.Format("2006-01-02 15:04:05"))
+}
+
+func TestNewDateTypesOutputParams(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	if conn.sybaseMode125() {
+		t.Skip("datettimeoffset does not exist in Sybase 12.5")
+	}
+	err := createProcedure(conn, "test_sp_with_datetime2_output", `
+    (@p1 datetime2(7), @p2 datetimeoffset(7), @o1 datetime2(7) output, @o2 datetimeoffset(7) output) as
+    select @o1 = @p1, @o2 = @p2
+    return `)
+	assert.Nil(t, err)
+	p1 := time.Date(2025, 12, 10, 12, 32, 10, 123456700, time.Local)
+	p2 := time.Date(2025, 12, 10, 12, 32, 10, 123456700, time.FixedZone("", 5*3600+30*60))
+	rst, err := conn.ExecSp("test_sp_with_datetime2_output", p1, p2)
+	assert.Nil(t, err)
+	var o1, o2 time.Time
+	assert.Nil(t, rst.ParamScan(&o1, &o2))
+	assert.True(t, p1.Equal(o1))
+	assert.True(t, p2.Equal(o2))
+	_, of := o2.Zone()
+	assert.Equal(t, 5*3600+30*60, of)
 }
 
 func TestExecSpWithVarcharMax(t *testing.T) {
@@ -550,3 +577,41 @@ func TestExecSpBadParameterDataType(t *testing.T) {
 	assert.Nil(t, err)
 
 }
+
+func TestExecSpNotRetriedInTransaction(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	err := createProcedure(conn, "test_sp_retry_error", " as raiserror('retry me', 16, 1)")
+	assert.Nil(t, err)
+	checks := 0
+	conn.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, Retryable: func(error) bool {
+		checks++
+		return true
+	}})
+
+	_, err = conn.ExecSp("test_sp_retry_error")
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, checks)
+
+	//transaction started in sql, not tracked by Begin
+	_, err = conn.Exec("begin transaction")
+	assert.Nil(t, err)
+	_, err = conn.ExecSp("test_sp_retry_error")
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, checks)
+	assert.Contains(t, conn.Error, "retry me")
+	assert.Nil(t, conn.Rollback())
+
+	//transaction started by database/sql
+	tx, err := (&MssqlConn{conn: conn}).BeginTx(context.Background(), driver.TxOptions{})
+	assert.Nil(t, err)
+	assert.True(t, conn.inTransaction)
+	_, err = conn.ExecSp("test_sp_retry_error")
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, checks)
+	assert.Nil(t, tx.Rollback())
+	assert.False(t, conn.inTransaction)
+}
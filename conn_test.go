@@ -681,3 +681,29 @@ func TestMessageNumbers(t *testing.T) {
 	assert.Equal(t, c.HasMessageNumber(msgnumOne), 2)
 	assert.Equal(t, c.HasMessageNumber(msgnumTwo), 1)
 }
+
+func TestLoginDefaults(t *testing.T) {
+	conn := &Conn{credentials: credentials{}}
+	assert.Equal(t, defaultLoginTimeout, conn.loginTimeout())
+	assert.Equal(t, "UTF-8", conn.charset())
+	conn = &Conn{credentials: credentials{loginTimeout: 3, charset: "CP1250"}}
+	assert.Equal(t, 3, conn.loginTimeout())
+	assert.Equal(t, "CP1250", conn.charset())
+}
+
+func TestConnectWithLoginOptions(t *testing.T) {
+	connStr := testDbConnStr(1) + ";app name=gofreetds test;workstation id=gofreetds-ws;login timeout=5;query timeout=30;packet size=8192"
+	conn, err := NewConn(connStr)
+	assert.Nil(t, err)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	if conn.sybaseMode() || conn.sybaseMode125() {
+		t.Skip("app_name and host_name are not available in Sybase")
+	}
+	results, err := conn.Exec("select app_name(), host_name()")
+	assert.Nil(t, err)
+	assert.Equal(t, "gofreetds test", results[0].Rows[0][0])
+	assert.Equal(t, "gofreetds-ws", results[0].Rows[0][1])
+}
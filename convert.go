@@ -11,6 +11,7 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"math/big"
 	"reflect"
 	"strconv"
 	"time"
@@ -71,6 +72,54 @@ func convertAssign(dest, src interface{}) error {
 			*d = s
 			return nil
 		}
+	case Decimal:
+		switch d := dest.(type) {
+		case *string:
+			if d == nil {
+				return errNilPtr
+			}
+			*d = s.String()
+			return nil
+		case *Decimal:
+			if d == nil {
+				return errNilPtr
+			}
+			*d = s
+			return nil
+		case *big.Rat:
+			if d == nil {
+				return errNilPtr
+			}
+			d.Set(s.Rat())
+			return nil
+		case *float64:
+			if d == nil {
+				return errNilPtr
+			}
+			*d = s.Float64()
+			return nil
+		}
+	case UniqueIdentifier:
+		switch d := dest.(type) {
+		case *string:
+			if d == nil {
+				return errNilPtr
+			}
+			*d = s.String()
+			return nil
+		case *UniqueIdentifier:
+			if d == nil {
+				return errNilPtr
+			}
+			*d = s
+			return nil
+		case *[]byte:
+			if d == nil {
+				return errNilPtr
+			}
+			*d = append([]byte{}, s[:]...)
+			return nil
+		}
 	case nil:
 		switch d := dest.(type) {
 		case *interface{}:
@@ -243,6 +292,9 @@ func asString(src interface{}) string {
 		return v
 	case []byte:
 		return string(v)
+	case Decimal:
+		//without trailing zeros so integral values can be parsed as int
+		return v.trimmedString()
 	}
 	return fmt.Sprintf("%v", src)
 }
@@ -19,6 +19,9 @@ const (
 
 	SYBCHAR      = 47
 	SYBVARCHAR   = 39  //varchar       string
+	XSYBVARCHAR  = 167 //varchar       string
+	SYBTEXT      = 35  //text          string
+	SYBNTEXT     = 99  //ntext         string
 	SYBNVARCHAR  = 103 //nvarchar      string
 	XSYBNVARCHAR = 231 //nvarchar      string
 	XSYBNCHAR    = 239 //nchar         string
@@ -36,15 +39,20 @@ const (
 	SYBDATETIME  = 61 //datetime      time.Time
 	SYBDATETIME4 = 58 //smalldatetime time.Time
 
+	SYBMSDATE           = 40 //date           time.Time
+	SYBMSTIME           = 41 //time           time.Time
+	SYBMSDATETIME2      = 42 //datetime2      time.Time
+	SYBMSDATETIMEOFFSET = 43 //datetimeoffset time.Time
+
 	SYBIMAGE      = 34  //image         []byte
 	SYBBINARY     = 45  //binary        []byte
 	SYBVARBINARY  = 37  //varbinary     []byte
 	XSYBVARBINARY = 165 //varbinary     []byte
 
-	SYBNUMERIC = 108
-	SYBDECIMAL = 106
+	SYBNUMERIC = 108 //numeric       Decimal
+	SYBDECIMAL = 106 //decimal       Decimal
 
-	SYBUNIQUE = 36 //uniqueidentifier string
+	SYBUNIQUE = 36 //uniqueidentifier UniqueIdentifier
 )
 
 var (
@@ -111,6 +119,13 @@ func sqlBufToType(datatype int, data []byte) interface{} {
 		binary.Read(buf, binary.LittleEndian, &mins)
 		value := sqlStartTime.Add(time.Duration(days) * time.Hour * 24).Add(time.Duration(mins) * time.Minute)
 		return toLocalTime(value)
+	case SYBMSDATE, SYBMSTIME, SYBMSDATETIME2, SYBMSDATETIMEOFFSET:
+		return dateTimeAllToTime(datatype, data)
+	case SYBUNIQUE:
+		if len(data) < 16 {
+			return nil
+		}
+		return uniqueIdentifierFromSqlBuf(data)
 	case SYBMONEY:
 		var high int32
 		var low uint32
@@ -133,6 +148,22 @@ func sqlBufToType(datatype int, data []byte) interface{} {
 		return data[0] == 1
 	case SYBIMAGE, SYBVARBINARY, SYBBINARY, XSYBVARBINARY:
 		return append([]byte{}, data[:len(data)-1]...) // make copy of data
+	case SYBDECIMAL, SYBNUMERIC:
+		//columns are bound as string, output params are DBNUMERIC struct
+		//first byte of DBNUMERIC is precision (1-38), lower than any character of the string form
+		if len(data) > 0 && data[0] <= decimalMaxPrecision {
+			if value, err := decimalFromSqlBuf(data); err == nil {
+				return value
+			}
+		}
+		str := string(data)
+		if i := strings.Index(str, "\x00"); i >= 0 {
+			str = str[:i]
+		}
+		if value, err := ParseDecimal(str); err == nil {
+			return value
+		}
+		return str
 
 	default: //string
 		len := strings.Index(string(data), "\x00")
@@ -144,6 +175,13 @@ func sqlBufToType(datatype int, data []byte) interface{} {
 }
 
 func typeToSqlBuf(datatype int, value interface{}, freetdsVersionGte095 bool) (data []byte, datalen int, err error) {
+	if p, ok := value.(TypedParam); ok {
+		//type of the param is known from the procedure or table, only value is used
+		if p.err != nil {
+			return nil, 0, p.err
+		}
+		value = p.Value
+	}
 	datalen = -1
 	buf := new(bytes.Buffer)
 	switch datatype {
@@ -252,6 +290,27 @@ func typeToSqlBuf(datatype int, value interface{}, freetdsVersionGte095 bool) (d
 		} else {
 			err = errors.New(fmt.Sprintf("Could not convert %T to time.Time.", value))
 		}
+	case SYBMSDATE, SYBMSTIME, SYBMSDATETIME2, SYBMSDATETIMEOFFSET:
+		var tm time.Time
+		if tm, err = toTime(value); err == nil {
+			data = timeToDateTimeAll(datatype, tm)
+			datalen = len(data)
+		}
+		return
+	case SYBUNIQUE:
+		var u UniqueIdentifier
+		if u, err = toUniqueIdentifier(value); err == nil {
+			data = u.toSqlBuf()
+			datalen = len(data)
+		}
+		return
+	case SYBDECIMAL, SYBNUMERIC:
+		var d Decimal
+		if d, err = toDecimal(value); err == nil {
+			data, err = d.toSqlBuf()
+			datalen = len(data)
+		}
+		return
 	case SYBIMAGE, SYBVARBINARY, SYBBINARY, XSYBVARBINARY:
 		if buf, ok := value.([]byte); ok {
 			data = append(buf, []byte{0}[0])
@@ -307,3 +366,143 @@ func typeToSqlBuf(datatype int, value interface{}, freetdsVersionGte095 bool) (d
 	datalen = len(data)
 	return
 }
+
+//Size of the DBDATETIMEALL struct used for date, time, datetime2 and datetimeoffset.
+const dbDateTimeAllSize = 16
+
+//Length of the longest date, time, datetime2 or datetimeoffset string,
+//used when FreeTDS has no DATETIME2BIND: 2006-01-02 15:04:05.9999999 -07:00
+const dateTimeAllStringSize = 34
+
+//DBDATETIMEALL flags, bit fields after the offset
+const (
+	dateTimeAllPrecMask  = 0x0007
+	dateTimeAllHasTime   = 0x2000
+	dateTimeAllHasDate   = 0x4000
+	dateTimeAllHasOffset = 0x8000
+)
+
+//Layouts accepted when string is sent as date, time, datetime2 or datetimeoffset param.
+var dateTimeAllLayouts = []string{
+	"2006-01-02 15:04:05.9999999 -07:00",
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.9999999",
+	"2006-01-02T15:04:05.9999999",
+	"2006-01-02",
+	"15:04:05.9999999",
+	"15:04",
+}
+
+func isDateTimeAllType(datatype int) bool {
+	return datatype == SYBMSDATE || datatype == SYBMSTIME ||
+		datatype == SYBMSDATETIME2 || datatype == SYBMSDATETIMEOFFSET
+}
+
+//Decodes DBDATETIMEALL struct:
+//  time   uint64 100ns units since midnight
+//  date   int32  days since 1900-01-01
+//  offset int16  minutes from UTC, date and time are in UTC when offset is set
+//  flags  uint16 time precision and has time/date/offset bits
+//Values without offset are in local timezone like datetime.
+func dateTimeAllToTime(datatype int, data []byte) interface{} {
+	if len(data) < dbDateTimeAllSize {
+		return nil
+	}
+	tm := binary.LittleEndian.Uint64(data[0:8])
+	days := int32(binary.LittleEndian.Uint32(data[8:12]))
+	offset := int16(binary.LittleEndian.Uint16(data[12:14]))
+	flags := binary.LittleEndian.Uint16(data[14:16])
+
+	value := sqlStartTime.AddDate(0, 0, int(days)).Add(time.Duration(tm) * 100)
+	if datatype == SYBMSDATETIMEOFFSET || flags&dateTimeAllHasOffset != 0 {
+		return value.In(time.FixedZone("", int(offset)*60))
+	}
+	return time.Date(value.Year(), value.Month(), value.Day(),
+		value.Hour(), value.Minute(), value.Second(), value.Nanosecond(), time.Local)
+}
+
+//Parses date, time, datetime2 or datetimeoffset value bound as null terminated string.
+//Returns the string when it can't be parsed.
+func parseDateTimeAllString(data []byte) interface{} {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+	if tm, err := toTime(string(data)); err == nil {
+		return tm
+	}
+	return string(data)
+}
+
+//Encodes time as DBDATETIMEALL struct.
+//Time is truncated to 100ns, the precision of datetime2.
+func timeToDateTimeAll(datatype int, value time.Time) []byte {
+	var offset int16
+	flags := uint16(7) & dateTimeAllPrecMask
+	if datatype == SYBMSDATETIMEOFFSET {
+		_, of := value.Zone()
+		offset = int16(of / 60)
+		value = value.UTC()
+		flags |= dateTimeAllHasOffset
+	} else {
+		value = value.Local()
+	}
+	//wall clock as if it was UTC, to avoid daylight saving gaps
+	wall := time.Date(value.Year(), value.Month(), value.Day(),
+		value.Hour(), value.Minute(), value.Second(), value.Nanosecond(), time.UTC)
+	midnight := time.Date(wall.Year(), wall.Month(), wall.Day(), 0, 0, 0, 0, time.UTC)
+
+	var tm uint64
+	var days int32
+	if datatype != SYBMSDATE {
+		tm = uint64(wall.Sub(midnight) / 100)
+		flags |= dateTimeAllHasTime
+	}
+	if datatype != SYBMSTIME {
+		days = int32(daysSince(sqlStartTime, midnight))
+		flags |= dateTimeAllHasDate
+	}
+
+	data := make([]byte, dbDateTimeAllSize)
+	binary.LittleEndian.PutUint64(data[0:8], tm)
+	binary.LittleEndian.PutUint32(data[8:12], uint32(days))
+	binary.LittleEndian.PutUint16(data[12:14], uint16(offset))
+	binary.LittleEndian.PutUint16(data[14:16], flags)
+	return data
+}
+
+//Number of days between two UTC midnights.
+//Duration can't hold the whole date range (years 1 to 9999) so days are counted in chunks.
+func daysSince(from, to time.Time) int64 {
+	const chunk = 100000
+	var days int64
+	for to.Sub(from) > chunk*24*time.Hour {
+		from = from.AddDate(0, 0, chunk)
+		days += chunk
+	}
+	for from.Sub(to) > chunk*24*time.Hour {
+		from = from.AddDate(0, 0, -chunk)
+		days -= chunk
+	}
+	return days + int64(to.Sub(from)/(24*time.Hour))
+}
+
+//Converts time.Time or string in one of dateTimeAllLayouts to time.
+//Strings without offset are in local timezone.
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range dateTimeAllLayouts {
+			if tm, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+				if tm.Year() == 0 {
+					//time only
+					tm = tm.AddDate(1900, 0, 0)
+				}
+				return tm, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("Could not parse %q as time.", v)
+	}
+	return time.Time{}, errors.New(fmt.Sprintf("Could not convert %T to time.Time.", value))
+}
@@ -112,3 +112,51 @@ func testToSqlToType(t *testing.T, typ int, value interface{}) {
 	value2 := sqlBufToType(typ, data)
 	assert.EqualValues(t, value, value2)
 }
+
+func TestDateTimeAll(t *testing.T) {
+	f := func(typ int, value time.Time, expected time.Time) {
+		data, datalen, err := typeToSqlBuf(typ, value, true)
+		assert.Nil(t, err)
+		assert.Equal(t, dbDateTimeAllSize, datalen)
+		value2, _ := sqlBufToType(typ, data).(time.Time)
+		if !expected.Equal(value2) {
+			t.Errorf("TestDateTimeAll %d %s != %s", typ, expected, value2)
+		}
+	}
+	tm := time.Date(2025, 12, 10, 12, 32, 10, 123456789, time.Local)
+	truncated := time.Date(2025, 12, 10, 12, 32, 10, 123456700, time.Local)
+	f(SYBMSDATETIME2, tm, truncated)
+	f(SYBMSDATETIME2, time.Date(1, 1, 1, 0, 0, 0, 0, time.Local), time.Date(1, 1, 1, 0, 0, 0, 0, time.Local))
+	f(SYBMSDATETIME2, time.Date(9999, 12, 31, 23, 59, 59, 999999900, time.Local), time.Date(9999, 12, 31, 23, 59, 59, 999999900, time.Local))
+	f(SYBMSDATE, tm, time.Date(2025, 12, 10, 0, 0, 0, 0, time.Local))
+	f(SYBMSTIME, tm, time.Date(1900, 1, 1, 12, 32, 10, 123456700, time.Local))
+
+	india := time.FixedZone("", 5*3600+30*60)
+	f(SYBMSDATETIMEOFFSET, tm.In(india), truncated)
+	data, _, _ := typeToSqlBuf(SYBMSDATETIMEOFFSET, tm.In(india), true)
+	_, of := sqlBufToType(SYBMSDATETIMEOFFSET, data).(time.Time).Zone()
+	assert.Equal(t, 5*3600+30*60, of)
+}
+
+func TestDateTimeAllFromString(t *testing.T) {
+	data, _, err := typeToSqlBuf(SYBMSDATETIMEOFFSET, "2025-12-10 12:32:10.1237000 +01:00", true)
+	assert.Nil(t, err)
+	value := sqlBufToType(SYBMSDATETIMEOFFSET, data).(time.Time)
+	assert.Equal(t, "2025-12-10 12:32:10.1237 +01:00", value.Format("2006-01-02 15:04:05.9999999 -07:00"))
+
+	data, _, err = typeToSqlBuf(SYBMSTIME, "12:30", true)
+	assert.Nil(t, err)
+	value = sqlBufToType(SYBMSTIME, data).(time.Time)
+	assert.Equal(t, "12:30:00", value.Format("15:04:05"))
+
+	_, _, err = typeToSqlBuf(SYBMSDATE, "pero", true)
+	assert.NotNil(t, err)
+}
+
+func TestParseDateTimeAllString(t *testing.T) {
+	value := parseDateTimeAllString([]byte("2025-12-10 12:32:10.1237000 +01:00\x00\x00"))
+	_, of := value.(time.Time).Zone()
+	assert.Equal(t, 3600, of)
+	assert.Equal(t, time.Date(2025, 12, 10, 0, 0, 0, 0, time.Local), parseDateTimeAllString([]byte("2025-12-10\x00")))
+	assert.Equal(t, "pero", parseDateTimeAllString([]byte("pero\x00")))
+}
@@ -0,0 +1,91 @@
+package freetds
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+//CredentialProvider supplies user and password for the login.
+//It is called on every new connection and reconnect, so rotated passwords
+//are used without rebuilding the pool.
+//
+//Refresh is true when the login with previously returned credentials failed (18456),
+//providers which cache credentials should fetch them again.
+//Empty user means the user from the connection string or Config.
+//
+//Example:
+//  cfg.CredentialProvider = freetds.FileCredentials("", "/run/secrets/db_password")
+//  pool, err := freetds.NewConnPoolFromConfig(cfg)
+type CredentialProvider interface {
+	Credentials(refresh bool) (user, password string, err error)
+}
+
+//CredentialProviderFunc adapts function to CredentialProvider.
+type CredentialProviderFunc func(refresh bool) (string, string, error)
+
+func (f CredentialProviderFunc) Credentials(refresh bool) (string, string, error) {
+	return f(refresh)
+}
+
+//EnvCredentials reads user and password from the environment variables on every login.
+//When userVar is empty the configured user is used.
+func EnvCredentials(userVar, passwordVar string) CredentialProvider {
+	return CredentialProviderFunc(func(bool) (string, string, error) {
+		var user string
+		if userVar != "" {
+			var ok bool
+			if user, ok = os.LookupEnv(userVar); !ok {
+				return "", "", fmt.Errorf("environment variable %s is not set", userVar)
+			}
+		}
+		password, ok := os.LookupEnv(passwordVar)
+		if !ok {
+			return "", "", fmt.Errorf("environment variable %s is not set", passwordVar)
+		}
+		return user, password, nil
+	})
+}
+
+//FileCredentials reads user and password from the secret files on every login,
+//trailing newline is removed. When userFile is empty the configured user is used.
+func FileCredentials(userFile, passwordFile string) CredentialProvider {
+	return CredentialProviderFunc(func(bool) (string, string, error) {
+		var user string
+		if userFile != "" {
+			var err error
+			if user, err = readSecretFile(userFile); err != nil {
+				return "", "", err
+			}
+		}
+		password, err := readSecretFile(passwordFile)
+		if err != nil {
+			return "", "", err
+		}
+		return user, password, nil
+	})
+}
+
+func readSecretFile(name string) (string, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+//User and password for the login, from the credential provider when it is set.
+func (conn *Conn) loginCredentials(refresh bool) (string, string, error) {
+	if conn.provider == nil {
+		return conn.user, conn.pwd, nil
+	}
+	user, pwd, err := conn.provider.Credentials(refresh)
+	if err != nil {
+		return "", "", fmt.Errorf("credential provider: %w", err)
+	}
+	if user == "" {
+		user = conn.user
+	}
+	return user, pwd, nil
+}
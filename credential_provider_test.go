@@ -0,0 +1,107 @@
+package freetds
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	os.Setenv("GOFREETDS_TEST_USER", "myUsername")
+	os.Setenv("GOFREETDS_TEST_PWD", "myPassword")
+	defer os.Unsetenv("GOFREETDS_TEST_USER")
+	defer os.Unsetenv("GOFREETDS_TEST_PWD")
+
+	user, pwd, err := EnvCredentials("GOFREETDS_TEST_USER", "GOFREETDS_TEST_PWD").Credentials(false)
+	assert.Nil(t, err)
+	assert.Equal(t, "myUsername", user)
+	assert.Equal(t, "myPassword", pwd)
+
+	user, pwd, err = EnvCredentials("", "GOFREETDS_TEST_PWD").Credentials(false)
+	assert.Nil(t, err)
+	assert.Equal(t, "", user)
+	assert.Equal(t, "myPassword", pwd)
+
+	_, _, err = EnvCredentials("", "GOFREETDS_TEST_MISSING").Credentials(false)
+	assert.EqualError(t, err, "environment variable GOFREETDS_TEST_MISSING is not set")
+}
+
+func TestFileCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gofreetds")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	userFile := filepath.Join(dir, "user")
+	pwdFile := filepath.Join(dir, "password")
+	assert.Nil(t, ioutil.WriteFile(userFile, []byte("myUsername\n"), 0600))
+	assert.Nil(t, ioutil.WriteFile(pwdFile, []byte("my Password\r\n"), 0600))
+
+	provider := FileCredentials(userFile, pwdFile)
+	user, pwd, err := provider.Credentials(false)
+	assert.Nil(t, err)
+	assert.Equal(t, "myUsername", user)
+	assert.Equal(t, "my Password", pwd)
+
+	//rotated password is read on the next login
+	assert.Nil(t, ioutil.WriteFile(pwdFile, []byte("newPassword"), 0600))
+	_, pwd, err = provider.Credentials(true)
+	assert.Nil(t, err)
+	assert.Equal(t, "newPassword", pwd)
+
+	_, _, err = FileCredentials("", filepath.Join(dir, "missing")).Credentials(false)
+	assert.NotNil(t, err)
+}
+
+func TestLoginCredentials(t *testing.T) {
+	conn := &Conn{credentials: credentials{user: "myUsername", pwd: "myPassword"}}
+	user, pwd, err := conn.loginCredentials(false)
+	assert.Nil(t, err)
+	assert.Equal(t, "myUsername", user)
+	assert.Equal(t, "myPassword", pwd)
+
+	var refreshed []bool
+	conn.provider = CredentialProviderFunc(func(refresh bool) (string, string, error) {
+		refreshed = append(refreshed, refresh)
+		return "", "rotatedPassword", nil
+	})
+	user, pwd, err = conn.loginCredentials(true)
+	assert.Nil(t, err)
+	assert.Equal(t, "myUsername", user)
+	assert.Equal(t, "rotatedPassword", pwd)
+	assert.Equal(t, []bool{true}, refreshed)
+
+	secretsErr := errors.New("secrets manager unavailable")
+	conn.provider = CredentialProviderFunc(func(bool) (string, string, error) {
+		return "", "", secretsErr
+	})
+	_, _, err = conn.loginCredentials(false)
+	assert.True(t, errors.Is(err, secretsErr))
+	assert.EqualError(t, err, "credential provider: secrets manager unavailable")
+}
+
+func TestCredentialProviderRetriesFailedLogin(t *testing.T) {
+	cfg, err := ParseDSN(testDbConnStr(1))
+	assert.Nil(t, err)
+	password := cfg.Password
+	var refreshed []bool
+	cfg.Password = ""
+	cfg.CredentialProvider = CredentialProviderFunc(func(refresh bool) (string, string, error) {
+		refreshed = append(refreshed, refresh)
+		if !refresh {
+			//stale password, before rotation
+			return "", "wrong" + password, nil
+		}
+		return "", password, nil
+	})
+	conn, err := NewConnFromConfig(cfg)
+	assert.Nil(t, err)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	assert.Equal(t, []bool{false, true}, refreshed)
+	assert.Equal(t, "", conn.pwd)
+}
@@ -0,0 +1,248 @@
+package freetds
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+//Max precision of the Sql Server decimal and numeric types.
+const decimalMaxPrecision = 38
+
+//Size of the DBNUMERIC struct: precision, scale and 33 bytes of sign and value.
+const dbNumericSize = 35
+
+//Size of the buffer for the decimal bound as string: 38 digits, sign, point, leading zero and terminating null.
+const decimalStringSize = 42
+
+//Number of bytes (including sign) used for the value in DBNUMERIC array, by precision.
+//Copied from FreeTDS tds_numbytesperprec.
+var numericBytesPerPrecision = [decimalMaxPrecision + 1]int{
+	-1, 2, 2, 3, 3, 4, 4, 4, 5, 5,
+	6, 6, 6, 7, 7, 8, 8, 9, 9, 9,
+	10, 10, 11, 11, 11, 12, 12, 13, 13, 14,
+	14, 14, 15, 15, 16, 16, 16, 17, 17,
+}
+
+//Decimal is exact value of the Sql Server decimal or numeric type.
+//
+//Decimal and numeric columns and output params are read as Decimal.
+//Decimal can be used as ExecuteSql or ExecSp param, and as Scan destination.
+type Decimal struct {
+	unscaled *big.Int
+	scale    uint8
+}
+
+//NewDecimal creates decimal with value unscaled * 10^-scale.
+func NewDecimal(unscaled *big.Int, scale uint8) Decimal {
+	return Decimal{unscaled: new(big.Int).Set(unscaled), scale: scale}
+}
+
+//ParseDecimal parses decimal from string like "-123.4500".
+//Scale is equal to the number of digits after decimal point.
+func ParseDecimal(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+	neg := false
+	if len(str) > 0 && (str[0] == '-' || str[0] == '+') {
+		neg = str[0] == '-'
+		str = str[1:]
+	}
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+	digits := intPart + fracPart
+	if len(digits) == 0 || len(fracPart) > decimalMaxPrecision {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+	}
+	unscaled, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		unscaled.Neg(unscaled)
+	}
+	return Decimal{unscaled: unscaled, scale: uint8(len(fracPart))}, nil
+}
+
+func (d Decimal) value() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+//Number of digits after decimal point.
+func (d Decimal) Scale() int {
+	return int(d.scale)
+}
+
+//Number of digits needed to store the value, at least scale + 1.
+func (d Decimal) Precision() int {
+	digits := len(new(big.Int).Abs(d.value()).String())
+	if digits <= int(d.scale) {
+		digits = int(d.scale) + 1
+	}
+	return digits
+}
+
+//Unscaled value, decimal is equal to unscaled * 10^-scale.
+func (d Decimal) Unscaled() *big.Int {
+	return new(big.Int).Set(d.value())
+}
+
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.value()).String()
+	if d.scale > 0 {
+		if len(s) <= int(d.scale) {
+			s = strings.Repeat("0", int(d.scale)-len(s)+1) + s
+		}
+		s = s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+	}
+	if d.value().Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+//String without trailing zeros in the fractional part.
+func (d Decimal) trimmedString() string {
+	s := d.String()
+	if d.scale > 0 {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	return s
+}
+
+//Exact value as big.Rat.
+func (d Decimal) Rat() *big.Rat {
+	denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale)), nil)
+	return new(big.Rat).SetFrac(d.value(), denom)
+}
+
+//Nearest float64 value.
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+//Rescale returns decimal with the new scale.
+//Value is rounded half away from zero when scale is reduced.
+func (d Decimal) Rescale(scale uint8) Decimal {
+	v := d.value()
+	if scale >= d.scale {
+		m := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-d.scale)), nil)
+		return Decimal{unscaled: new(big.Int).Mul(v, m), scale: scale}
+	}
+	m := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale-scale)), nil)
+	q, r := new(big.Int).QuoRem(v, m, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(m) >= 0 {
+		q.Add(q, big.NewInt(int64(v.Sign())))
+	}
+	return Decimal{unscaled: q, scale: scale}
+}
+
+//Implements sql.Scanner.
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case Decimal:
+		*d = v
+		return nil
+	case string:
+		dv, err := ParseDecimal(v)
+		if err == nil {
+			*d = dv
+		}
+		return err
+	case []byte:
+		return d.Scan(string(v))
+	case int64:
+		*d = Decimal{unscaled: big.NewInt(v)}
+		return nil
+	case float64:
+		return d.Scan(strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return fmt.Errorf("can't convert %T to Decimal", src)
+}
+
+//Implements driver.Valuer.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+//Converts supported go types to decimal.
+func toDecimal(value interface{}) (Decimal, error) {
+	switch v := value.(type) {
+	case Decimal:
+		return v, nil
+	case *big.Rat:
+		return ratToDecimal(v)
+	case float32:
+		return ParseDecimal(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	case float64:
+		return ParseDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return ParseDecimal(fmt.Sprintf("%d", v))
+	case string:
+		return ParseDecimal(v)
+	}
+	return Decimal{}, fmt.Errorf("Could not convert %T to decimal.", value)
+}
+
+//Converts rational number to decimal with the smallest scale which represents it exactly.
+//Fails when there is no such scale up to the max precision, e.g. for 1/3.
+func ratToDecimal(r *big.Rat) (Decimal, error) {
+	ten := big.NewInt(10)
+	pow := big.NewInt(1)
+	rem := new(big.Int)
+	for scale := 0; scale <= decimalMaxPrecision; scale++ {
+		if rem.Rem(pow, r.Denom()).Sign() == 0 {
+			unscaled := new(big.Int).Mul(r.Num(), new(big.Int).Quo(pow, r.Denom()))
+			return Decimal{unscaled: unscaled, scale: uint8(scale)}, nil
+		}
+		pow.Mul(pow, ten)
+	}
+	return Decimal{}, fmt.Errorf("%s can't be converted to decimal exactly", r.RatString())
+}
+
+//Encodes decimal as DBNUMERIC struct.
+func (d Decimal) toSqlBuf() ([]byte, error) {
+	precision := d.Precision()
+	if precision > decimalMaxPrecision {
+		return nil, fmt.Errorf("decimal %s exceeds max precision %d", d, decimalMaxPrecision)
+	}
+	data := make([]byte, dbNumericSize)
+	data[0] = byte(precision)
+	data[1] = d.scale
+	v := d.value()
+	if v.Sign() < 0 {
+		data[2] = 1
+	}
+	n := numericBytesPerPrecision[precision]
+	mag := new(big.Int).Abs(v).Bytes()
+	copy(data[2+n-len(mag):2+n], mag)
+	return data, nil
+}
+
+//Decodes DBNUMERIC struct.
+func decimalFromSqlBuf(data []byte) (Decimal, error) {
+	if len(data) < 3 || data[0] == 0 || data[0] > decimalMaxPrecision {
+		return Decimal{}, errors.New("invalid numeric data")
+	}
+	precision, scale := int(data[0]), data[1]
+	n := numericBytesPerPrecision[precision]
+	if len(data) < 2+n {
+		return Decimal{}, errors.New("invalid numeric data")
+	}
+	v := new(big.Int).SetBytes(data[3 : 2+n])
+	if data[2] == 1 {
+		v.Neg(v)
+	}
+	return Decimal{unscaled: v, scale: scale}, nil
+}
@@ -0,0 +1,119 @@
+package freetds
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	d, err := ParseDecimal("-123.4500")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, d.Scale())
+	assert.Equal(t, 7, d.Precision())
+	assert.Equal(t, "-123.4500", d.String())
+	assert.Equal(t, "-123.45", d.trimmedString())
+	assert.Equal(t, big.NewInt(-1234500), d.Unscaled())
+
+	d, err = ParseDecimal(".5")
+	assert.Nil(t, err)
+	assert.Equal(t, "0.5", d.String())
+
+	for _, s := range []string{"", "-", "1.2.3", "12a", "1e10"} {
+		_, err = ParseDecimal(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestDecimalRescale(t *testing.T) {
+	d, _ := ParseDecimal("1.005")
+	assert.Equal(t, "1.01", d.Rescale(2).String())
+	assert.Equal(t, "1.00500", d.Rescale(5).String())
+	d, _ = ParseDecimal("-1.005")
+	assert.Equal(t, "-1.01", d.Rescale(2).String())
+	assert.Equal(t, "-1", d.Rescale(0).String())
+}
+
+func TestRatToDecimal(t *testing.T) {
+	d, err := toDecimal(big.NewRat(-5, 8))
+	assert.Nil(t, err)
+	assert.Equal(t, "-0.625", d.String())
+	d, err = toDecimal(big.NewRat(42, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, "42", d.String())
+	r, _ := new(big.Rat).SetString("1234567890.1234567890123456789")
+	d, err = toDecimal(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "1234567890.1234567890123456789", d.String())
+
+	_, err = toDecimal(big.NewRat(1, 3))
+	assert.NotNil(t, err)
+	_, err = toDecimal(new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Exp(big.NewInt(10), big.NewInt(39), nil)))
+	assert.NotNil(t, err)
+}
+
+func TestDecimalSqlBuf(t *testing.T) {
+	for _, s := range []string{"0", "1.26", "-1.26", "12345678901234567890.123456789012345678", "-0.00000001"} {
+		d, _ := ParseDecimal(s)
+		data, err := d.toSqlBuf()
+		assert.Nil(t, err)
+		assert.Equal(t, dbNumericSize, len(data))
+		d2, err := decimalFromSqlBuf(data)
+		assert.Nil(t, err)
+		assert.Equal(t, s, d2.String())
+	}
+	d, _ := ParseDecimal("123456789012345678901234567890123456789")
+	_, err := d.toSqlBuf()
+	assert.NotNil(t, err)
+}
+
+func TestDecimalSqlBufToType(t *testing.T) {
+	value := sqlBufToType(SYBDECIMAL, []byte("-1234.5678\x00"))
+	assert.Equal(t, "-1234.5678", value.(Decimal).String())
+
+	d, _ := ParseDecimal("1.27")
+	data, _ := d.toSqlBuf()
+	value = sqlBufToType(SYBNUMERIC, data)
+	assert.Equal(t, "1.27", value.(Decimal).String())
+
+	data, _, err := typeToSqlBuf(SYBDECIMAL, 1.25, false)
+	assert.Nil(t, err)
+	d, _ = decimalFromSqlBuf(data)
+	assert.Equal(t, "1.25", d.String())
+}
+
+func TestDecimalConvertAssign(t *testing.T) {
+	d, _ := ParseDecimal("42.00")
+	var i int64
+	assert.Nil(t, convertAssign(&i, d))
+	assert.Equal(t, int64(42), i)
+	var f float64
+	assert.Nil(t, convertAssign(&f, d))
+	assert.Equal(t, 42.0, f)
+	var s string
+	assert.Nil(t, convertAssign(&s, d))
+	assert.Equal(t, "42.00", s)
+	var d2 Decimal
+	assert.Nil(t, convertAssign(&d2, d))
+	assert.Equal(t, d, d2)
+	var r big.Rat
+	assert.Nil(t, convertAssign(&r, d))
+	assert.Equal(t, "42", r.RatString())
+}
+
+func TestDecimalRoundTrip(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+
+	d, _ := ParseDecimal("1234567890123456789012345678.0123456789")
+	results, err := conn.ExecuteSql("select cast(? as decimal(38,10)) d", d)
+	assert.Nil(t, err)
+	results[0].Next()
+	var d2 Decimal
+	assert.Nil(t, results[0].Scan(&d2))
+	assert.Equal(t, d.String(), d2.String())
+}
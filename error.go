@@ -0,0 +1,177 @@
+package freetds
+
+import (
+	"context"
+	"errors"
+)
+
+//Message received from the server by the message handler.
+type ServerMessage struct {
+	Number    int
+	Severity  int
+	State     int
+	Server    string
+	Procedure string
+	Line      int
+	Text      string
+}
+
+//Error returned when the server or DB-Library reports an error.
+//
+//Messages holds all server messages received during the call, including informational ones.
+//DbErr, OsErr, Severity and OsErrText are taken from the first DB-Library error.
+//
+//Use errors.As to get the details:
+//  var e *freetds.Error
+//  if errors.As(err, &e) && e.Number() == 2627 {
+//    //duplicate key
+//  }
+type Error struct {
+	DbErr     int
+	OsErr     int
+	Severity  int
+	OsErrText string
+	Messages  []ServerMessage
+	text      string
+	hasDbErr  bool
+}
+
+func (e *Error) Error() string {
+	return e.text
+}
+
+//Number of the first server error message (severity greater than 10).
+//If there is no such message number of the first message is returned, or 0 if there are no messages.
+func (e *Error) Number() int {
+	for _, m := range e.Messages {
+		if m.Severity > 10 {
+			return m.Number
+		}
+	}
+	if len(e.Messages) > 0 {
+		return e.Messages[0].Number
+	}
+	return 0
+}
+
+//Does the error contain server message with msgno number.
+func (e *Error) HasNumber(msgno int) bool {
+	for _, m := range e.Messages {
+		if m.Number == msgno {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Error) addDbError(dberr, oserr, severity int, oserrText string) {
+	if e.hasDbErr {
+		return
+	}
+	e.hasDbErr = true
+	e.DbErr = dberr
+	e.OsErr = oserr
+	e.Severity = severity
+	e.OsErrText = oserrText
+}
+
+func (e *Error) addMessage(m ServerMessage) {
+	e.Messages = append(e.Messages, m)
+}
+
+//Copy of the error with text set.
+func (e *Error) withText(text string) *Error {
+	c := *e
+	c.Messages = append([]ServerMessage{}, e.Messages...)
+	c.text = text
+	return &c
+}
+
+//Server message numbers used by the error classification helpers.
+const (
+	msgDeadlock          = 1205
+	msgLockTimeout       = 1222
+	msgDuplicateKey      = 2627
+	msgDuplicateKeyIndex = 2601
+	msgConstraintFailed  = 547
+	msgMirrorDatabase    = 954
+	msgRestoring         = 927
+	msgMirrorNoQuorum    = 955
+	msgLoginFailed       = 18456
+)
+
+//DB-Library error numbers used by the error classification helpers.
+const (
+	dbErrTimeout    = 20003 //SYBETIME
+	dbErrRead       = 20004 //SYBEREAD
+	dbErrWrite      = 20006 //SYBEWRIT
+	dbErrConnect    = 20009 //SYBECONN
+	dbErrEOF        = 20017 //SYBESEOF
+	dbErrDeadDbProc = 20047 //SYBEDDNE
+)
+
+//Is err reporting transaction chosen as deadlock victim (1205).
+func IsDeadlock(err error) bool {
+	return hasMessageNumber(err, msgDeadlock)
+}
+
+//Is err reporting lock request timeout (1222), see lock_timeout connection string param.
+func IsLockTimeout(err error) bool {
+	return hasMessageNumber(err, msgLockTimeout)
+}
+
+//Is err reporting primary key or unique constraint violation (2627, 2601).
+func IsDuplicateKey(err error) bool {
+	return hasMessageNumber(err, msgDuplicateKey, msgDuplicateKeyIndex)
+}
+
+//Is err reporting foreign key (or check) constraint violation (547).
+func IsForeignKeyViolation(err error) bool {
+	return hasMessageNumber(err, msgConstraintFailed)
+}
+
+//Is err caused by the lost connection to the server.
+func IsConnectionLost(err error) bool {
+	return hasDbErr(err, dbErrRead, dbErrWrite, dbErrConnect, dbErrEOF, dbErrDeadDbProc)
+}
+
+//Is err reporting that database is mirror or in restore, so the failover partner should be used.
+func IsMirrorFailover(err error) bool {
+	return hasMessageNumber(err, msgMirrorDatabase, msgRestoring, msgMirrorNoQuorum)
+}
+
+//Is err reporting failed login (18456), wrong user or password.
+func IsLoginFailed(err error) bool {
+	return hasMessageNumber(err, msgLoginFailed)
+}
+
+//Is err caused by the query timeout or context deadline.
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || hasDbErr(err, dbErrTimeout)
+}
+
+func hasMessageNumber(err error, msgnos ...int) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	for _, msgno := range msgnos {
+		if e.HasNumber(msgno) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDbErr(err error, dberrs ...int) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	for _, dberr := range dberrs {
+		if e.DbErr == dberr {
+			return true
+		}
+	}
+	return false
+}
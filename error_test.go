@@ -0,0 +1,128 @@
+package freetds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorNumber(t *testing.T) {
+	e := &Error{}
+	assert.Equal(t, 0, e.Number())
+	e.addMessage(ServerMessage{Number: 5701, Severity: 0})
+	assert.Equal(t, 5701, e.Number())
+	e.addMessage(ServerMessage{Number: 2627, Severity: 14})
+	e.addMessage(ServerMessage{Number: 3621, Severity: 0})
+	assert.Equal(t, 2627, e.Number())
+	assert.True(t, e.HasNumber(3621))
+	assert.False(t, e.HasNumber(547))
+}
+
+func TestErrorAddDbError(t *testing.T) {
+	e := &Error{}
+	e.addDbError(20018, 0, 16, "")
+	e.addDbError(20047, 0, 9, "")
+	assert.Equal(t, 20018, e.DbErr)
+	assert.Equal(t, 16, e.Severity)
+}
+
+func TestErrorWithText(t *testing.T) {
+	e := &Error{}
+	e.addMessage(ServerMessage{Number: 2627, Severity: 14})
+	err := error(e.withText("error text"))
+	e.addMessage(ServerMessage{Number: 547, Severity: 16})
+	assert.Equal(t, "error text", err.Error())
+	var sqlErr *Error
+	assert.True(t, errors.As(err, &sqlErr))
+	assert.Equal(t, 1, len(sqlErr.Messages))
+}
+
+func TestErrorFromServer(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	_, err := conn.Exec("raiserror('pero', 16, 3)")
+	assert.NotNil(t, err)
+	var sqlErr *Error
+	assert.True(t, errors.As(err, &sqlErr))
+	assert.Equal(t, 50000, sqlErr.Number())
+	assert.Equal(t, "pero", sqlErr.Messages[0].Text)
+	assert.Equal(t, 16, sqlErr.Messages[0].Severity)
+	assert.Equal(t, 3, sqlErr.Messages[0].State)
+}
+
+func TestErrorProcedureName(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	if conn.sybaseMode125() {
+		t.Skip("test procedure uses Sql Server syntax")
+	}
+	_, err := conn.Exec(`
+if exists(select * from sys.procedures where name = 'freetds_raise_error')
+  drop procedure freetds_raise_error
+`)
+	assert.Nil(t, err)
+	_, err = conn.Exec(`
+create procedure freetds_raise_error as
+  raiserror('pero', 16, 1)`)
+	assert.Nil(t, err)
+	_, err = conn.ExecSp("freetds_raise_error")
+	assert.NotNil(t, err)
+	var sqlErr *Error
+	assert.True(t, errors.As(err, &sqlErr))
+	assert.Equal(t, "freetds_raise_error", sqlErr.Messages[0].Procedure)
+	assert.Contains(t, err.Error(), "Procedure 'freetds_raise_error'")
+}
+
+func TestErrorClassification(t *testing.T) {
+	withMessage := func(msgno int) error {
+		e := &Error{}
+		e.addMessage(ServerMessage{Number: msgno, Severity: 16})
+		return fmt.Errorf("wrapped: %w", e.withText("error"))
+	}
+	withDbErr := func(dberr int) error {
+		e := &Error{}
+		e.addDbError(dberr, 0, 6, "")
+		return e.withText("error")
+	}
+
+	assert.True(t, IsDeadlock(withMessage(1205)))
+	assert.False(t, IsDeadlock(withMessage(1222)))
+	assert.True(t, IsLockTimeout(withMessage(1222)))
+	assert.True(t, IsDuplicateKey(withMessage(2627)))
+	assert.True(t, IsDuplicateKey(withMessage(2601)))
+	assert.True(t, IsForeignKeyViolation(withMessage(547)))
+	assert.True(t, IsMirrorFailover(withMessage(954)))
+	assert.True(t, IsLoginFailed(withMessage(18456)))
+	assert.False(t, IsLoginFailed(withMessage(4060)))
+	assert.True(t, IsConnectionLost(withDbErr(20047)))
+	assert.False(t, IsConnectionLost(withMessage(1205)))
+	assert.True(t, IsTimeout(withDbErr(20003)))
+	assert.True(t, IsTimeout(context.DeadlineExceeded))
+
+	assert.False(t, IsDeadlock(nil))
+	assert.False(t, IsDeadlock(errors.New("Msg 1205")))
+}
+
+func TestIsDuplicateKey(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	if conn.sybaseMode125() {
+		t.Skip("test uses Sql Server syntax")
+	}
+	_, err := conn.Exec("declare @t table (id int primary key); insert into @t values (1); insert into @t values (1)")
+	assert.NotNil(t, err)
+	assert.True(t, IsDuplicateKey(err))
+	assert.False(t, IsDeadlock(err))
+}
@@ -1,9 +1,14 @@
 package freetds
 
 import (
+	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -20,80 +25,205 @@ const statusRowSybase125 string = `
 //Execute sql query with arguments.
 //? in query are arguments placeholders.
 //  ExecuteSql("select * from authors where au_fname = ?", "John")
+//Arguments created with sql.Named are bound to @name placeholders, which can be used several times in the query.
+//  ExecuteSql("select * from authors where au_fname = @name or au_lname = @name", sql.Named("name", "John"))
 func (conn *Conn) ExecuteSql(query string, params ...driver.Value) ([]*Result, error) {
+	sql, err := conn.executeSqlStatement(query, true, params...)
+	if err != nil {
+		return nil, err
+	}
+	results, err := conn.Exec(sql)
+	return skipInsertResults(results, tvpInserts(params)), err
+}
+
+//Execute sql query with named arguments.
+//Params is map[string]interface{} or struct, map keys or exported struct field names
+//are bound to @name placeholders in query.
+//  ExecuteSqlNamed("select * from authors where au_fname = @name", map[string]interface{}{"name": "John"})
+func (conn *Conn) ExecuteSqlNamed(query string, params interface{}) ([]*Result, error) {
+	named, err := namedParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return conn.ExecuteSql(query, named...)
+}
+
+//Converts map or struct to list of sql.NamedArg params.
+//Map keys are sorted so the generated statement is always the same.
+func namedParams(params interface{}) ([]driver.Value, error) {
+	v := reflect.Indirect(reflect.ValueOf(params))
+	named := make([]driver.Value, 0)
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			named = append(named, sql.Named(k, v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key())).Interface()))
+		}
+	case v.Kind() == reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" {
+				//unexported
+				continue
+			}
+			named = append(named, sql.Named(f.Name, v.Field(i).Interface()))
+		}
+	default:
+		return nil, fmt.Errorf("named params should be map[string]interface{} or struct, got %T", params)
+	}
+	return named, nil
+}
+
+//Builds sql batch which executes query with params.
+//When withStatusRow is set batch returns additional result with last_insert_id and rows_affected.
+func (conn *Conn) executeSqlStatement(query string, withStatusRow bool, params ...driver.Value) (string, error) {
 	if conn.sybaseMode125() {
-		return conn.executeSqlSybase125(query, params...)
+		return executeSqlSybase125Statement(query, withStatusRow, params...)
+	}
+	s, err := conn.sqlStatement(query, conn.credentials.paramSizes, withStatusRow, params...)
+	if err != nil {
+		return "", err
 	}
+
+	sql := fmt.Sprintf("exec sp_executesql N'%s', N'%s', %s", s.statement, s.paramDef, s.paramVal)
+
+	if len(params) == 0 {
+		sql = fmt.Sprintf("exec sp_executesql N'%s'", s.statement)
+	}
+	return s.declare + sql, nil
+}
+
+//Query converted for sp_executesql or sp_prepare.
+type sqlStatement struct {
+	//declarations of table variables for table-valued params
+	declare string
+	//quoted statement with @p1, @p2, ... params
+	statement string
+	//params definition and values
+	paramDef string
+	paramVal string
+}
+
+//Params are sized according to paramSizes mode, see param_sizes option.
+func (conn *Conn) sqlStatement(query string, paramSizes string, withStatusRow bool, params ...driver.Value) (*sqlStatement, error) {
 	statement, numParams := query2Statement(query)
-	if numParams != len(params) {
-		return nil, fmt.Errorf("Incorrect number of params, expecting %d got %d", numParams, len(params))
+	if numParams != numPositional(params) {
+		return nil, paramsCountError(query, placeholders(query), numPositional(params))
 	}
+	declare, params, err := declareTVPs(params)
+	if err != nil {
+		return nil, err
+	}
+	params = conn.sizeParams(paramSizes, statement, params)
 	paramDef, paramVal, err := parseParams(params...)
 	if err != nil {
 		return nil, err
 	}
 
-	statement += statusRow
-
-	sql := fmt.Sprintf("exec sp_executesql N'%s', N'%s', %s", statement, paramDef, paramVal)
-
-	if numParams == 0 {
-		sql = fmt.Sprintf("exec sp_executesql N'%s'", statement)
+	if withStatusRow {
+		statement += statusRow
 	}
-	return conn.Exec(sql)
+	return &sqlStatement{declare: declare, statement: statement, paramDef: paramDef, paramVal: paramVal}, nil
 }
 
 func (conn *Conn) executeSqlSybase125(query string, params ...driver.Value) ([]*Result, error) {
-	statement, numParams := query2Statement(query)
-	if numParams != len(params) {
-		return nil, fmt.Errorf("Incorrect number of params, expecting %d got %d", numParams, len(params))
+	sql, err := executeSqlSybase125Statement(query, true, params...)
+	if err != nil {
+		return nil, err
 	}
+	return conn.Exec(sql)
+}
 
-	statement += statusRowSybase125
-	sql := strings.Replace(query, "?", "$bindkey", -1)
-	re, _ := regexp.Compile(`(?P<bindkey>\$bindkey)`)
-	matches := re.FindAllSubmatchIndex([]byte(sql), -1)
-
-	for i, _ := range matches {
-		_, escapedValue, _ := go2SqlDataType(params[i])
-		sql = fmt.Sprintf("%s", strings.Replace(sql, "$bindkey", escapedValue, 1))
+func executeSqlSybase125Statement(query string, withStatusRow bool, params ...driver.Value) (string, error) {
+	if numPositional(params) != len(params) {
+		return "", errors.New("named params are not supported in Sybase compatibility mode")
+	}
+	offsets := placeholders(query)
+	if len(offsets) != len(params) {
+		return "", paramsCountError(query, offsets, len(params))
 	}
 
-	if numParams == 0 {
-		sql = fmt.Sprintf("%s", statement)
+	sql := replacePlaceholders(query, offsets, func(i int) string {
+		_, escapedValue, _ := go2SqlDataType(params[i])
+		return escapedValue
+	})
+	//as before, status row is selected only for queries without params
+	if withStatusRow && len(params) == 0 {
+		sql += statusRowSybase125
 	}
-	return conn.Exec(sql)
+	return sql, nil
 }
 
 //converts query to SqlServer statement for sp_executesql
-//replaces ? in query with params @p1, @p2, ...
+//replaces ? placeholders in query with params @p1, @p2, ...
 //returns statement and number of params
 func query2Statement(query string) (string, int) {
-	parts := strings.Split(query, "?")
-	var statement string
-	numParams := len(parts) - 1
-	statement = parts[0]
-	for i, part := range parts {
-		if i > 0 {
-			statement = fmt.Sprintf("%s@p%d%s", statement, i, part)
+	offsets := placeholders(query)
+	statement := replacePlaceholders(query, offsets, func(i int) string {
+		return fmt.Sprintf("@p%d", i+1)
+	})
+	return quote(statement), len(offsets)
+}
+
+//Number of params which are not named.
+func numPositional(params []driver.Value) int {
+	n := 0
+	for _, param := range params {
+		if _, ok := param.(sql.NamedArg); !ok {
+			n++
 		}
 	}
-	return quote(statement), numParams
+	return n
 }
 
+//Names of named params are valid identifiers, with or without leading @.
+var paramNameRe = regexp.MustCompile(`^@?[\pL_#][\pL\pN_#$@]*$`)
+
+//Names of positional params, @p1, @p2, ...
+var positionalNameRe = regexp.MustCompile(`(?i)^@?p([1-9]\d*)$`)
+
+//Returns sp_executesql params definition and values.
+//Positional params are named @p1, @p2, ..., named params keep their names.
 func parseParams(params ...driver.Value) (string, string, error) {
 	paramDef := ""
 	paramVal := ""
+	names := make(map[string]bool)
+	position := 0
+	positional := numPositional(params)
 	for i, param := range params {
 		if i > 0 {
 			paramVal += ", "
 			paramDef += ", "
 		}
+		var paramName string
+		if named, ok := param.(sql.NamedArg); ok {
+			if !paramNameRe.MatchString(named.Name) {
+				return "", "", fmt.Errorf("invalid param name %s", named.Name)
+			}
+			if m := positionalNameRe.FindStringSubmatch(named.Name); m != nil {
+				if n, _ := strconv.Atoi(m[1]); n <= positional {
+					return "", "", fmt.Errorf("named param %s collides with positional param @p%d", named.Name, n)
+				}
+			}
+			paramName = "@" + strings.TrimPrefix(named.Name, "@")
+			param = named.Value
+		} else {
+			position++
+			paramName = fmt.Sprintf("@p%d", position)
+		}
+		key := strings.ToLower(paramName)
+		if names[key] {
+			return "", "", fmt.Errorf("param %s is given more than once", paramName)
+		}
+		names[key] = true
 		sqlType, sqlValue, err := go2SqlDataType(param)
 		if err != nil {
 			return "", "", err
 		}
-		paramName := fmt.Sprintf("@p%d", i+1)
 		paramDef += fmt.Sprintf("%s %s", paramName, sqlType)
 		paramVal += fmt.Sprintf("%s=%s", paramName, sqlValue)
 	}
@@ -104,6 +234,57 @@ func quote(in string) string {
 	return strings.Replace(in, "'", "''", -1)
 }
 
+//Quotes each part of the multipart object name with brackets, dbo.IdList becomes [dbo].[IdList].
+//Parts already quoted with brackets or double quotes are requoted, so quoting is idempotent.
+//Empty parts, as in db..name, are kept.
+func quoteName(name string) string {
+	if name == "" {
+		return ""
+	}
+	parts := make([]string, 0, 2)
+	part := make([]byte, 0, len(name))
+	quoted := false
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '.':
+			parts = append(parts, bracketPart(part, quoted))
+			part, quoted = part[:0], false
+		case (c == '[' || c == '"') && !quoted && len(strings.TrimSpace(string(part))) == 0:
+			closing := byte('"')
+			if c == '[' {
+				closing = ']'
+			}
+			part, quoted = part[:0], true
+			for i++; i < len(name); i++ {
+				if name[i] == closing {
+					if i+1 < len(name) && name[i+1] == closing {
+						i++
+					} else {
+						break
+					}
+				}
+				part = append(part, name[i])
+			}
+		default:
+			part = append(part, c)
+		}
+	}
+	parts = append(parts, bracketPart(part, quoted))
+	return strings.Join(parts, ".")
+}
+
+func bracketPart(part []byte, quoted bool) string {
+	s := string(part)
+	if !quoted {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return ""
+		}
+	}
+	return "[" + strings.Replace(s, "]", "]]", -1) + "]"
+}
+
 func go2SqlDataType(value interface{}) (string, string, error) {
 	max := func(a int, b int) int {
 		if a > b {
@@ -130,12 +311,24 @@ func go2SqlDataType(value interface{}) (string, string, error) {
 		return "bigint", strValue, nil
 	case float32, float64:
 		return "real", strValue, nil
+	case Decimal:
+		return fmt.Sprintf("decimal (%d, %d)", t.Precision(), t.Scale()), t.String(), nil
+	case UniqueIdentifier:
+		return "uniqueidentifier", fmt.Sprintf("'%s'", t), nil
+	case TypedParam:
+		if t.err != nil {
+			return "", "", t.err
+		}
+		return t.Type, t.literal, nil
+	case tvpVariable:
+		return fmt.Sprintf("%s readonly", t.typeName), t.name, nil
 	case string:
 		{
 		}
 	case time.Time:
 		{
-			strValue = t.Format(time.RFC3339Nano)
+			//datetimeoffset precision is 100ns
+			strValue = t.Format("2006-01-02T15:04:05.9999999-07:00")
 			return "datetimeoffset", fmt.Sprintf("'%s'", quote(strValue)), nil
 		}
 	case []byte:
@@ -1,6 +1,8 @@
 package freetds
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"testing"
 	"time"
 
@@ -55,6 +57,26 @@ func TestQuery2Statement(t *testing.T) {
 	s, p = query2Statement("select 1 where 2 = ? and 3 = ?")
 	assert.Equal(t, p, 2)
 	assert.Equal(t, s, "select 1 where 2 = @p1 and 3 = @p2")
+
+	s, p = query2Statement("select 'why?' where url like 'http://%?%' and 3 = ? -- ?")
+	assert.Equal(t, p, 1)
+	assert.Equal(t, s, "select ''why?'' where url like ''http://%?%'' and 3 = @p1 -- ?")
+}
+
+func TestExecuteSqlSybase125Statement(t *testing.T) {
+	sql, err := executeSqlSybase125Statement("select * from authors where au_id = ? and au_lname <> '?'", false, "172-32-1176")
+	assert.Nil(t, err)
+	assert.Equal(t, "select * from authors where au_id = '172-32-1176' and au_lname <> '?'", sql)
+	_, err = executeSqlSybase125Statement("select ?, ?", false, 1)
+	assert.NotNil(t, err)
+
+	//status row only for queries without params
+	sql, err = executeSqlSybase125Statement("update authors set au_lname = ?", true, "x")
+	assert.Nil(t, err)
+	assert.Equal(t, "update authors set au_lname = 'x'", sql)
+	sql, err = executeSqlSybase125Statement("update authors set au_lname = 'x'", true)
+	assert.Nil(t, err)
+	assert.Equal(t, "update authors set au_lname = 'x'"+statusRowSybase125, sql)
 }
 
 func TestGoTo2SqlDataType(t *testing.T) {
@@ -100,6 +122,78 @@ func TestParseParams(t *testing.T) {
 	assert.Equal(t, val, "@p1=1, @p2=2, @p3='pero'")
 }
 
+func TestParseParamsNamed(t *testing.T) {
+	def, val, err := parseParams(1, sql.Named("name", "pero"), sql.Named("@id", 2), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "@p1 int, @name nvarchar (4), @id int, @p2 int", def)
+	assert.Equal(t, "@p1=1, @name='pero', @id=2, @p2=3", val)
+
+	_, _, err = parseParams(sql.Named("id", 1), sql.Named("ID", 2))
+	assert.NotNil(t, err)
+	_, _, err = parseParams(1, sql.Named("p1", 2))
+	assert.NotNil(t, err)
+	_, _, err = parseParams(sql.Named("@P2", 1), 2, 3)
+	assert.Equal(t, "named param @P2 collides with positional param @p2", err.Error())
+	//not used by positional params
+	_, _, err = parseParams(1, sql.Named("p2", 2))
+	assert.Nil(t, err)
+	_, _, err = parseParams(sql.Named("id = 1; drop table authors --", 1))
+	assert.NotNil(t, err)
+}
+
+func TestExecuteSqlStatementNamed(t *testing.T) {
+	conn := &Conn{credentials: credentials{}}
+	stmt, err := conn.executeSqlStatement("select * from authors where au_fname = @name or au_lname = @name", false, sql.Named("name", "pero"))
+	assert.Nil(t, err)
+	assert.Equal(t, "exec sp_executesql N'select * from authors where au_fname = @name or au_lname = @name', N'@name nvarchar (4)', @name='pero'", stmt)
+
+	stmt, err = conn.executeSqlStatement("select * from authors where au_id = ? and contract = @contract", false, "172-32-1176", sql.Named("contract", true))
+	assert.Nil(t, err)
+	assert.Equal(t, "exec sp_executesql N'select * from authors where au_id = @p1 and contract = @contract', N'@p1 nvarchar (11), @contract bit', @p1='172-32-1176', @contract=1", stmt)
+
+	_, err = conn.executeSqlStatement("select ?", false, sql.Named("id", 1))
+	assert.NotNil(t, err)
+}
+
+func TestNamedParams(t *testing.T) {
+	params, err := namedParams(map[string]interface{}{"name": "pero", "id": 1})
+	assert.Nil(t, err)
+	assert.Equal(t, []driver.Value{sql.Named("id", 1), sql.Named("name", "pero")}, params)
+
+	type author struct {
+		Id     int
+		Name   string
+		secret string
+	}
+	params, err = namedParams(&author{Id: 1, Name: "pero", secret: "x"})
+	assert.Nil(t, err)
+	assert.Equal(t, []driver.Value{sql.Named("Id", 1), sql.Named("Name", "pero")}, params)
+
+	_, err = namedParams(1)
+	assert.NotNil(t, err)
+}
+
+func TestExecuteSqlNamed(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	if conn.sybaseMode125() {
+		t.Skip("named params are not supported in Sybase 12.5")
+	}
+	results, err := conn.ExecuteSql("select @a + @b + @a sum", sql.Named("a", 1), sql.Named("b", 2))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(results))
+	assert.EqualValues(t, 4, results[0].Rows[0][0])
+
+	results, err = conn.ExecuteSqlNamed("select @a + @b sum", struct{ A, B int }{A: 1, B: 2})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 3, results[0].Rows[0][0])
+
+	results, err = conn.ExecuteSqlNamed("select @a + ? sum", map[string]interface{}{"a": 1})
+	assert.NotNil(t, err)
+}
+
 func TestExecuteSqlDatetime(t *testing.T) {
 	c := ConnectToTestDb(t)
 	var err error
@@ -0,0 +1,274 @@
+package freetds
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+/*
+#include <stdlib.h>
+#include <sybfront.h>
+#include <sybdb.h>
+*/
+import "C"
+
+//Format of the exported data.
+type ExportFormat int
+
+const (
+	//Comma separated values, fields with delimiter, quote or new line are quoted.
+	ExportCSV ExportFormat = iota
+	//Tab separated values, backslash, tab, carriage return and new line in fields
+	//are escaped as \\, \t, \r and \n.
+	ExportTSV
+	//Native bcp format, can be loaded back with bcp -n.
+	ExportNative
+)
+
+//ExportOptions controls Export.
+//Delimiters, Null and DateFormat are not used for the native format.
+type ExportOptions struct {
+	Format ExportFormat
+	//Field delimiter, "," for CSV and "\t" for TSV when empty.
+	Delimiter string
+	//Row terminator, "\n" when empty.
+	RowTerminator string
+	//Written instead of NULL values, empty string by default.
+	Null string
+	//Layout for time values, "2006-01-02 15:04:05.9999999" when empty.
+	DateFormat string
+	//Write column names in the first row.
+	Header bool
+}
+
+const defaultExportDateFormat = "2006-01-02 15:04:05.9999999"
+
+//Export executes query and writes its rows to w.
+//Rows are read from the server one by one, so results of any size can be exported.
+//For the native format rows are copied with bcp queryout into temporary file and then to w.
+//Returns number of exported rows.
+//
+//Example:
+//  f, err := os.Create("authors.csv")
+//  n, err := conn.Export(f, "select * from authors", freetds.ExportOptions{Header: true})
+func (conn *Conn) Export(w io.Writer, query string, options ExportOptions) (int64, error) {
+	if options.Format == ExportNative {
+		return conn.exportNative(w, query)
+	}
+	rows, err := conn.Query(query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	e := newExporter(w, options)
+	if options.Header {
+		names := make([]string, len(rows.Columns()))
+		for i, c := range rows.Columns() {
+			names[i] = e.escape(c.Name)
+		}
+		if err := e.writeRow(names); err != nil {
+			return 0, err
+		}
+	}
+	var n int64
+	fields := make([]string, len(rows.Columns()))
+	for rows.Next() {
+		for i, value := range rows.values {
+			fields[i] = e.format(value)
+		}
+		if err := e.writeRow(fields); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	return n, e.w.Flush()
+}
+
+type exporter struct {
+	w       *bufio.Writer
+	options ExportOptions
+}
+
+func newExporter(w io.Writer, options ExportOptions) *exporter {
+	if options.Delimiter == "" {
+		options.Delimiter = ","
+		if options.Format == ExportTSV {
+			options.Delimiter = "\t"
+		}
+	}
+	if options.RowTerminator == "" {
+		options.RowTerminator = "\n"
+	}
+	if options.DateFormat == "" {
+		options.DateFormat = defaultExportDateFormat
+	}
+	return &exporter{w: bufio.NewWriter(w), options: options}
+}
+
+func (e *exporter) writeRow(fields []string) error {
+	for i, field := range fields {
+		if i > 0 {
+			if _, err := e.w.WriteString(e.options.Delimiter); err != nil {
+				return err
+			}
+		}
+		if e.options.Format == ExportCSV && e.needsQuotes(field) {
+			field = `"` + strings.Replace(field, `"`, `""`, -1) + `"`
+		}
+		if _, err := e.w.WriteString(field); err != nil {
+			return err
+		}
+	}
+	_, err := e.w.WriteString(e.options.RowTerminator)
+	return err
+}
+
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\r", `\r`, "\n", `\n`)
+
+func (e *exporter) needsQuotes(field string) bool {
+	return strings.Contains(field, e.options.Delimiter) ||
+		strings.Contains(field, e.options.RowTerminator) ||
+		strings.ContainsAny(field, "\"\r\n")
+}
+
+//Escapes special characters of TSV, Null option is written as it is.
+func (e *exporter) escape(field string) string {
+	if e.options.Format == ExportTSV {
+		return tsvEscaper.Replace(field)
+	}
+	return field
+}
+
+//Formats value read by sqlBufToType.
+func (e *exporter) format(value interface{}) string {
+	if value == nil {
+		return e.options.Null
+	}
+	return e.escape(e.formatValue(value))
+}
+
+func (e *exporter) formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(e.options.DateFormat)
+	case []byte:
+		return hex.EncodeToString(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+//Exports query in the native format using bcp queryout.
+//Db-lib bcp writes only to the host file, so rows are copied through the temporary file.
+func (conn *Conn) exportNative(w io.Writer, query string) (int64, error) {
+	//bcp needs number of columns before the query is executed
+	cols, err := conn.resultColumns(query)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := ioutil.TempFile("", "gofreetds-bcp-")
+	if err != nil {
+		return 0, err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	conn.clearMessages()
+	cquery := C.CString(query)
+	defer C.free(unsafe.Pointer(cquery))
+	cfile := C.CString(f.Name())
+	defer C.free(unsafe.Pointer(cfile))
+	if C.bcp_init(conn.dbproc, cquery, cfile, nil, C.DB_QUERYOUT) == C.FAIL {
+		return 0, conn.raiseError("bcp_init failed")
+	}
+	if C.bcp_columns(conn.dbproc, C.int(cols)) == C.FAIL {
+		return 0, conn.raiseError("bcp_columns failed")
+	}
+	for i := 1; i <= cols; i++ {
+		//host type 0 is native type of the column, prefix and length are defaults for the type
+		if C.bcp_colfmt(conn.dbproc, C.int(i), 0, -1, -1, nil, 0, C.int(i)) == C.FAIL {
+			return 0, conn.raiseError("bcp_colfmt failed")
+		}
+	}
+	var copied C.DBINT
+	if C.bcp_exec(conn.dbproc, &copied) == C.FAIL {
+		return int64(copied), conn.raiseError("bcp_exec failed")
+	}
+
+	f, err = os.Open(f.Name())
+	if err != nil {
+		return int64(copied), err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return int64(copied), err
+}
+
+//Number of columns in the first result of the query, which is not executed.
+//Sql Server describes it with sp_describe_first_result_set, Sybase with set fmtonly.
+func (conn *Conn) resultColumns(query string) (int, error) {
+	if conn.sybaseMode() || conn.sybaseMode125() {
+		return conn.fmtOnlyColumns(query)
+	}
+	results, err := conn.Exec(fmt.Sprintf("exec sp_describe_first_result_set N'%s'", quote(query)))
+	if err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, errors.New("sp_describe_first_result_set returned no result")
+	}
+	hidden, err := results[0].FindColumn("is_hidden")
+	if err != nil {
+		return 0, err
+	}
+	cols := 0
+	for _, row := range results[0].Rows {
+		if isHidden, _ := row[hidden].(bool); !isHidden {
+			cols++
+		}
+	}
+	if cols == 0 {
+		return 0, errors.New("query returns no result set")
+	}
+	return cols, nil
+}
+
+func (conn *Conn) fmtOnlyColumns(query string) (int, error) {
+	results, err := conn.Exec(fmt.Sprintf("set fmtonly on\n%s\nset fmtonly off", query))
+	if err != nil {
+		//batch aborting error skips set fmtonly off, session would return no rows for later queries
+		if !conn.isDead() {
+			conn.exec("set fmtonly off")
+		}
+		return 0, err
+	}
+	for _, r := range results {
+		if len(r.Columns) > 0 {
+			return len(r.Columns), nil
+		}
+	}
+	return 0, errors.New("query returns no result set")
+}
@@ -0,0 +1,68 @@
+package freetds
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExporterCSV(t *testing.T) {
+	buf := &bytes.Buffer{}
+	e := newExporter(buf, ExportOptions{Null: "NULL"})
+	tm := time.Date(2014, 1, 5, 23, 24, 0, 500000000, time.Local)
+	d, _ := ParseDecimal("1.2500")
+	values := []interface{}{int32(1), "a,b", `say "hi"`, nil, tm, []byte{1, 171}, true, 1.5, d}
+	fields := make([]string, len(values))
+	for i, v := range values {
+		fields[i] = e.format(v)
+	}
+	assert.Nil(t, e.writeRow(fields))
+	assert.Nil(t, e.w.Flush())
+	assert.Equal(t, `1,"a,b","say ""hi""",NULL,2014-01-05 23:24:00.5,01ab,1,1.5,1.2500`+"\n", buf.String())
+}
+
+func TestExporterTSV(t *testing.T) {
+	buf := &bytes.Buffer{}
+	e := newExporter(buf, ExportOptions{Format: ExportTSV, RowTerminator: "\r\n", DateFormat: "2006-01-02", Null: `\N`})
+	tm := time.Date(2014, 1, 5, 23, 24, 0, 0, time.Local)
+	assert.Nil(t, e.writeRow([]string{e.format("a,b"), e.format(tm), e.format(nil), e.format("a\tb\r\nc\\d")}))
+	assert.Nil(t, e.w.Flush())
+	assert.Equal(t, "a,b\t2014-01-05\t\\N\ta\\tb\\r\\nc\\\\d\r\n", buf.String())
+}
+
+func TestExport(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+
+	buf := &bytes.Buffer{}
+	n, err := conn.Export(buf, "select au_id, au_lname from authors where au_id = '172-32-1176'", ExportOptions{Header: true})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, n)
+	assert.Equal(t, "au_id,au_lname\n172-32-1176,White\n", buf.String())
+
+	buf.Reset()
+	n, err = conn.Export(buf, "select au_id, au_lname from authors", ExportOptions{Format: ExportNative})
+	assert.Nil(t, err)
+	assert.True(t, n > 0)
+	assert.True(t, strings.Contains(buf.String(), "White"))
+}
+
+func TestExportNativeError(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+
+	_, err := conn.Export(&bytes.Buffer{}, "select * from export_no_such_table", ExportOptions{Format: ExportNative})
+	assert.NotNil(t, err)
+	val, err := conn.SelectValue("select 1")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, val)
+}
@@ -12,6 +12,18 @@ import (
 static int my_dbcount(DBPROCESS * dbproc) {
  return DBCOUNT(dbproc);
 }
+
+//DATETIME2BIND is missing in older FreeTDS, date and time types are bound as strings there
+#ifdef DATETIME2BIND
+#define MY_DATETIME2BIND DATETIME2BIND
+#else
+#define MY_DATETIME2BIND NTBSTRINGBIND
+#endif
+
+static RETCODE my_dbcolinfo(DBPROCESS * dbproc, int column, DBCOL * col) {
+ col->SizeOfStruct = sizeof(DBCOL);
+ return dbcolinfo(dbproc, CI_REGULAR, column, 0, col);
+}
 */
 import "C"
 
@@ -27,104 +39,21 @@ func (conn *Conn) fetchResults() ([]*Result, error) {
 		}
 		result := NewResult()
 		conn.currentResult = result
-		cols := int(C.dbnumcols(conn.dbproc))
-		columns := make([]column, cols)
-		for i := 0; i < cols; i++ {
-			no := C.int(i + 1)
-			name := C.GoString(C.dbcolname(conn.dbproc, no))
-			size := C.dbcollen(conn.dbproc, no)
-			typ := C.dbcoltype(conn.dbproc, no)
-			if typ == SYBUNIQUE {
-				size = 36
-			}
-			if typ == SYBNUMERIC || typ==SYBDECIMAL {
-				size = 8
-			}
-			bindTyp, typ := dbbindtype(typ)
-			result.addColumn(name, int(size), int(typ))
-			if bindTyp == C.NTBSTRINGBIND && C.SYBCHAR != typ && C.SYBTEXT != typ && XSYBXML != typ {
-				size = C.DBINT(C.dbwillconvert(typ, C.SYBCHAR))
-			}
-			col := &columns[i]
-			// detecting varchar(max) or varbinary(max) types
-			col.canVary = (size == 2147483647 && typ == SYBCHAR) ||
-				(size == 2147483647 && typ == XSYBXML) ||
-				(size == 1073741823 && typ == SYBBINARY) ||
-				(size == 64512 && typ == SYBIMAGE) //varbinary(MAX)
-
-			col.name = name
-			col.typ = int(typ)
-			col.size = int(size)
-			col.bindTyp = int(bindTyp)
-			// If row data can vary, don't bind it now, read the data later using C.dbdata when scanning rows.
-			if !col.canVary {
-				col.buffer = make([]byte, size+1)
-				erc = C.dbbind(conn.dbproc, no, bindTyp, size+1, (*C.BYTE)(&col.buffer[0]))
-				//fmt.Printf("dbbind %d, %d, %v\n", bindTyp, size+1, col.buffer)
-				if erc == C.FAIL {
-					return nil, errors.New("dbbind failed: no such column or no such conversion possible, or target buffer too small")
-				}
-			}
-			// We still use dbnullbind for all variable and non variable columns. Should work fine.
-			erc = C.dbnullbind(conn.dbproc, no, &col.status)
-			if erc == C.FAIL {
-				return nil, errors.New("dbnullbind failed")
-			}
+		columns, err := conn.bindColumns(result)
+		if err != nil {
+			return nil, err
 		}
 
-	rows_loop:
 		for i := 0; ; i++ {
-			switch C.dbnextrow(conn.dbproc) {
-			case C.NO_MORE_ROWS:
-				break rows_loop
-			case C.BUF_FULL:
-				return nil, errors.New("dbnextrow failed: Buffer Full")
-			case C.FAIL:
-				return nil, errors.New("dbnextrow failed: Failure")
-			case C.REG_ROW:
-				for j := 0; j < cols; j++ {
-					col := columns[j]
-					//fmt.Printf("col: %#v\nvalue:%s\n", col, col.Value())
-
-					no := C.int(j + 1)
-					// if canVary is true, we don't rely on dbbind to do it's thing,
-					// but instead we will ask C.dbdata() for pointer to the data.
-					// We cannot call C.dbbind here, because for that it's too late (we already called C.dbnextrow()).
-					if col.canVary {
-						// actual size for this row
-						// dbdata returns null if data are null.
-						// Source: http://lists.ibiblio.org/pipermail/freetds/2015q2/029392.html
-						//    From Sybase documentation:
-						//    "A NULL BYTE pointer is returned if there is no such column or if the
-						//    data has a null value. To make sure that the data is really a null
-						//    value, you should always check for a return of 0 from *dbdatlen*."
-						//
-						//    From Microsoft documentation:
-						//    "A NULL BYTE pointer is returned if there is no such column or if the
-						//    data has a null value. To make sure that the data is really a null
-						//    value, check for a return of 0 from *dbdatlen*."
-						//
-						//    So you can use: dbdata()==nil && dbdatlen()==0
-						// @see http://www.freetds.org/reference/a00341.html#gaee60c306a22383805a4b9caa647a1e16
-						size := C.dbdatlen(conn.dbproc, no)
-						data := C.dbdata(conn.dbproc, no)
-						if data == nil && size != 0 {
-							return nil, errors.New("dbdata failed: server returned non-nil data with size 0")
-						}
-						if data != nil {
-							// @see https://github.com/golang/go/wiki/cgo
-							if col.typ == SYBBINARY || col.typ == SYBIMAGE {
-								size++
-							}
-							//fmt.Printf("col.typ: %d\n", col.typ)
-							col.buffer = C.GoBytes(unsafe.Pointer(data), C.int(size))
-						}
-					}
-
-					result.addValue(i, j, col.Value())
-				}
-			default:
-				// Continue looping
+			values, err := conn.nextRow(columns)
+			if err != nil {
+				return nil, err
+			}
+			if values == nil {
+				break
+			}
+			for j, value := range values {
+				result.addValue(i, j, value)
 			}
 		}
 
@@ -143,6 +72,142 @@ func (conn *Conn) fetchResults() ([]*Result, error) {
 	return results, nil
 }
 
+//Adds columns of the current result set to the result,
+//and binds column buffers for reading rows.
+func (conn *Conn) bindColumns(result *Result) ([]column, error) {
+	cols := int(C.dbnumcols(conn.dbproc))
+	columns := make([]column, cols)
+	for i := 0; i < cols; i++ {
+		no := C.int(i + 1)
+		name := C.GoString(C.dbcolname(conn.dbproc, no))
+		size := C.dbcollen(conn.dbproc, no)
+		typ := C.dbcoltype(conn.dbproc, no)
+		bindTyp, typ := dbbindtype(typ)
+		result.addColumn(name, int(size), int(typ))
+		conn.columnInfo(no, result.Columns[i])
+		if typ == SYBNUMERIC || typ == SYBDECIMAL {
+			//bound as string to preserve precision
+			size = decimalStringSize
+		} else if isDateTimeAllType(int(typ)) {
+			size = dbDateTimeAllSize
+			if bindTyp == C.NTBSTRINGBIND {
+				size = dateTimeAllStringSize
+			}
+		} else if typ == SYBUNIQUE {
+			size = 16
+		} else if bindTyp == C.NTBSTRINGBIND && C.SYBCHAR != typ && C.SYBTEXT != typ && XSYBXML != typ {
+			size = C.DBINT(C.dbwillconvert(typ, C.SYBCHAR))
+		}
+		col := &columns[i]
+		// detecting varchar(max) or varbinary(max) types
+		col.canVary = (size == 2147483647 && typ == SYBCHAR) ||
+			(size == 2147483647 && typ == XSYBXML) ||
+			(size == 1073741823 && typ == SYBBINARY) ||
+			(size == 64512 && typ == SYBIMAGE) //varbinary(MAX)
+
+		col.name = name
+		col.typ = int(typ)
+		col.size = int(size)
+		col.bindTyp = int(bindTyp)
+		// If row data can vary, don't bind it now, read the data later using C.dbdata when scanning rows.
+		if !col.canVary {
+			col.buffer = make([]byte, size+1)
+			erc := C.dbbind(conn.dbproc, no, bindTyp, size+1, (*C.BYTE)(&col.buffer[0]))
+			//fmt.Printf("dbbind %d, %d, %v\n", bindTyp, size+1, col.buffer)
+			if erc == C.FAIL {
+				return nil, errors.New("dbbind failed: no such column or no such conversion possible, or target buffer too small")
+			}
+		}
+		// We still use dbnullbind for all variable and non variable columns. Should work fine.
+		erc := C.dbnullbind(conn.dbproc, no, &col.status)
+		if erc == C.FAIL {
+			return nil, errors.New("dbnullbind failed")
+		}
+	}
+	return columns, nil
+}
+
+//Reads column metadata with dbcolinfo and dbcoltypeinfo.
+func (conn *Conn) columnInfo(no C.int, c *ResultColumn) {
+	var col C.DBCOL
+	varLength := false
+	if C.my_dbcolinfo(conn.dbproc, no, &col) != C.FAIL {
+		c.hasColInfo = true
+		c.Nullable = col.Null == C.TRUE
+		c.Identity = col.Identity == C.TRUE
+		c.Table = C.GoString(&col.TableName[0])
+		varLength = col.VarLength == C.TRUE
+	}
+	c.TypeName = sqlTypeName(c.DbType, varLength)
+	if c.DbType == SYBDECIMAL || c.DbType == SYBNUMERIC {
+		if info := C.dbcoltypeinfo(conn.dbproc, no); info != nil {
+			c.Precision = int(info.precision)
+			c.Scale = int(info.scale)
+		}
+	}
+}
+
+//Reads next regular row of the current result set.
+//Returns nil values when there are no more rows.
+func (conn *Conn) nextRow(columns []column) ([]interface{}, error) {
+	for {
+		switch C.dbnextrow(conn.dbproc) {
+		case C.NO_MORE_ROWS:
+			return nil, nil
+		case C.BUF_FULL:
+			return nil, errors.New("dbnextrow failed: Buffer Full")
+		case C.FAIL:
+			return nil, errors.New("dbnextrow failed: Failure")
+		case C.REG_ROW:
+			values := make([]interface{}, len(columns))
+			for j := 0; j < len(columns); j++ {
+				col := columns[j]
+				//fmt.Printf("col: %#v\nvalue:%s\n", col, col.Value())
+
+				no := C.int(j + 1)
+				// if canVary is true, we don't rely on dbbind to do it's thing,
+				// but instead we will ask C.dbdata() for pointer to the data.
+				// We cannot call C.dbbind here, because for that it's too late (we already called C.dbnextrow()).
+				if col.canVary {
+					// actual size for this row
+					// dbdata returns null if data are null.
+					// Source: http://lists.ibiblio.org/pipermail/freetds/2015q2/029392.html
+					//    From Sybase documentation:
+					//    "A NULL BYTE pointer is returned if there is no such column or if the
+					//    data has a null value. To make sure that the data is really a null
+					//    value, you should always check for a return of 0 from *dbdatlen*."
+					//
+					//    From Microsoft documentation:
+					//    "A NULL BYTE pointer is returned if there is no such column or if the
+					//    data has a null value. To make sure that the data is really a null
+					//    value, check for a return of 0 from *dbdatlen*."
+					//
+					//    So you can use: dbdata()==nil && dbdatlen()==0
+					// @see http://www.freetds.org/reference/a00341.html#gaee60c306a22383805a4b9caa647a1e16
+					size := C.dbdatlen(conn.dbproc, no)
+					data := C.dbdata(conn.dbproc, no)
+					if data == nil && size != 0 {
+						return nil, errors.New("dbdata failed: server returned non-nil data with size 0")
+					}
+					if data != nil {
+						// @see https://github.com/golang/go/wiki/cgo
+						if col.typ == SYBBINARY || col.typ == SYBIMAGE {
+							size++
+						}
+						//fmt.Printf("col.typ: %d\n", col.typ)
+						col.buffer = C.GoBytes(unsafe.Pointer(data), C.int(size))
+					}
+				}
+
+				values[j] = col.Value()
+			}
+			return values, nil
+		default:
+			// Continue looping
+		}
+	}
+}
+
 type column struct {
 	name    string
 	typ     int
@@ -157,14 +222,17 @@ func (col *column) Value() interface{} {
 	if col.status == -1 {
 		return nil
 	}
+	if col.bindTyp == C.NTBSTRINGBIND && isDateTimeAllType(col.typ) {
+		return parseDateTimeAllString(col.buffer)
+	}
 	return sqlBufToType(col.typ, col.buffer)
 }
 
 func dbbindtype(datatype C.int) (C.int, C.int) {
 	switch datatype {
-	//this will map decimal, and numeric datatypes to float
+	//decimal and numeric are read as string and converted to Decimal
 	case C.SYBDECIMAL, C.SYBNUMERIC:
-		return C.FLT8BIND, C.SYBFLT8
+		return C.NTBSTRINGBIND, datatype
 		//for all other types return datatype as second param
 	case C.SYBIMAGE, C.SYBVARBINARY, C.SYBBINARY:
 		return C.BINARYBIND, datatype
@@ -176,6 +244,8 @@ func dbbindtype(datatype C.int) (C.int, C.int) {
 		return C.DATETIMEBIND, datatype
 	case C.SYBDATETIME4:
 		return C.SMALLDATETIMEBIND, datatype
+	case SYBMSDATE, SYBMSTIME, SYBMSDATETIME2, SYBMSDATETIMEOFFSET:
+		return C.MY_DATETIME2BIND, datatype
 	case C.SYBFLT8:
 		return C.FLT8BIND, datatype
 	case C.SYBREAL:
@@ -193,7 +263,7 @@ func dbbindtype(datatype C.int) (C.int, C.int) {
 	case C.SYBMONEY4:
 		return C.SMALLMONEYBIND, datatype
 	case SYBUNIQUE:
-		return C.STRINGBIND, C.SYBCHAR
+		return C.BINARYBIND, datatype
 	}
 	//TODO - log unknown datatype
 	return C.NTBSTRINGBIND, datatype
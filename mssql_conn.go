@@ -1,8 +1,11 @@
 package freetds
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
+	"fmt"
 )
 
 //register driver for use with database/sql package
@@ -22,6 +25,58 @@ func (d *MssqlDriver) Open(dsn string) (driver.Conn, error) {
 	return &MssqlConn{conn: conn}, nil
 }
 
+//implements OpenConnector for DriverContext interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+//Connection string is parsed once, not for every new connection.
+func (d *MssqlDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	cfg, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return NewConnector(cfg), nil
+}
+
+//Connector implements Connector interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+//Use it to open database/sql DB from Config, without connection string.
+//Connections opened by the connector share stored procedure and params caches.
+//
+//Example:
+//  db := sql.OpenDB(freetds.NewConnector(cfg))
+type Connector struct {
+	crd           *credentials
+	spParamsCache *ParamsCache
+	paramTypes    *paramTypesCache
+}
+
+//NewConnector creates connector with options from the config.
+//Later changes of the config don't affect the connector.
+func NewConnector(cfg *Config) *Connector {
+	return &Connector{
+		crd:           cfg.credentials(),
+		spParamsCache: NewParamsCache(),
+		paramTypes:    newParamTypesCache(),
+	}
+}
+
+//implements Connect for Connector interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+//Login can't be interrupted, context is checked before it.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	conn, err := connectWithCredentials(c.crd)
+	if err != nil {
+		return nil, err
+	}
+	conn.spParamsCache = c.spParamsCache
+	conn.paramTypes = c.paramTypes
+	return &MssqlConn{conn: conn}, nil
+}
+
+//implements Driver for Connector interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (c *Connector) Driver() driver.Driver {
+	return &MssqlDriver{}
+}
+
 //implements Conn interface from http://golang.org/src/pkg/database/sql/driver/driver.go
 type MssqlConn struct {
 	conn *Conn
@@ -29,11 +84,95 @@ type MssqlConn struct {
 
 //implements Prepare for Conn interface from http://golang.org/src/pkg/database/sql/driver/driver.go
 func (c *MssqlConn) Prepare(query string) (driver.Stmt, error) {
-	_, numInput := query2Statement(query)
-	s := &MssqlStmt{query: query, numInput: numInput, conn: c.conn}
+	s := &MssqlStmt{query: query, numInput: numInput(query), conn: c.conn}
 	return s, nil
 }
 
+//implements PrepareContext for ConnPrepareContext interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (c *MssqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Prepare(query)
+}
+
+//implements ExecContext for ExecerContext interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (c *MssqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if spName, ok := spCall(query, args); ok {
+		result, err := c.conn.execSpNamedValues(ctx, spName, args)
+		if err != nil {
+			return nil, err
+		}
+		return &MssqlSpResult{result: result}, nil
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	results, err := c.conn.ExecuteSqlContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	return &MssqlResult{results: results}, nil
+}
+
+//implements QueryContext for QueryerContext interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (c *MssqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if spName, ok := spCall(query, args); ok {
+		result, err := c.conn.execSpNamedValues(ctx, spName, args)
+		if err != nil {
+			return nil, err
+		}
+		return newMssqlSpRows(result), nil
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := c.conn.QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	return &MssqlRows{rows: rows}, nil
+}
+
+//implements CheckNamedValue for NamedValueChecker interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (c *MssqlConn) CheckNamedValue(nv *driver.NamedValue) error {
+	return checkNamedValue(nv)
+}
+
+//implements Ping for Pinger interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (c *MssqlConn) Ping(ctx context.Context) error {
+	if c.conn.isDead() {
+		return driver.ErrBadConn
+	}
+	if _, err := c.conn.ExecContext(ctx, "select 1"); err != nil {
+		if c.conn.isDead() {
+			return driver.ErrBadConn
+		}
+		return err
+	}
+	return nil
+}
+
+//implements ResetSession for SessionResetter interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+//Server session is not reset, db-lib has no sp_reset_connection: temp tables, set options and
+//transactions begun in sql stay with the connection. State changed by the driver itself is reset:
+//isolation level of BeginTx is restored when the transaction ends, messages of the previous use are cleared here.
+//Prepared statement handles are kept for reuse.
+func (c *MssqlConn) ResetSession(ctx context.Context) error {
+	if c.conn.isDead() {
+		return driver.ErrBadConn
+	}
+	c.conn.clearMessages()
+	return nil
+}
+
+//implements IsValid for Validator interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (c *MssqlConn) IsValid() bool {
+	return !c.conn.isDead()
+}
+
 //implements Close for Conn interface from http://golang.org/src/pkg/database/sql/driver/driver.go
 func (c *MssqlConn) Close() error {
 	c.conn.Close()
@@ -43,27 +182,96 @@ func (c *MssqlConn) Close() error {
 //implements Begin for Conn interface from http://golang.org/src/pkg/database/sql/driver/driver.go
 func (c *MssqlConn) Begin() (driver.Tx, error) {
 	t := &MssqlConnTx{conn: c.conn}
-	return t, t.begin()
+	return t, t.begin(context.Background())
+}
+
+//implements BeginTx for ConnBeginTx interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+//Read-only transactions are not supported by Sql Server.
+func (c *MssqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.ReadOnly {
+		return nil, errors.New("read-only transactions are not supported")
+	}
+	level, err := isolationLevel(sql.IsolationLevel(opts.Isolation))
+	if err != nil {
+		return nil, err
+	}
+	t := &MssqlConnTx{conn: c.conn, isolation: level}
+	return t, t.begin(ctx)
+}
+
+//Maps database/sql isolation level to the Sql Server isolation level name.
+//Returns empty string for the default level.
+func isolationLevel(level sql.IsolationLevel) (string, error) {
+	switch level {
+	case sql.LevelDefault:
+		return "", nil
+	case sql.LevelReadUncommitted:
+		return "read uncommitted", nil
+	case sql.LevelReadCommitted:
+		return "read committed", nil
+	case sql.LevelRepeatableRead:
+		return "repeatable read", nil
+	case sql.LevelSnapshot:
+		return "snapshot", nil
+	case sql.LevelSerializable:
+		return "serializable", nil
+	}
+	return "", fmt.Errorf("isolation level %s is not supported", level)
 }
 
 //implements Tx interface from http://golang.org/src/pkg/database/sql/driver/driver.go
 type MssqlConnTx struct {
-	conn *Conn
+	conn      *Conn
+	isolation string
+	//level of the session before transaction, restored when it ends
+	previousIsolation string
 }
 
-func (t *MssqlConnTx) begin() error {
-	_, err := t.conn.Exec("begin transaction")
+//Isolation level names by sys.dm_exec_sessions.transaction_isolation_level.
+var sessionIsolationLevels = []string{"", "read uncommitted", "read committed", "repeatable read", "serializable", "snapshot"}
+
+func (t *MssqlConnTx) begin(ctx context.Context) error {
+	sql := "begin transaction"
+	if t.isolation != "" {
+		sql = fmt.Sprintf("set transaction isolation level %s\n%s", t.isolation, sql)
+		if !t.conn.sybaseMode() {
+			sql = "select transaction_isolation_level from sys.dm_exec_sessions where session_id = @@spid\n" + sql
+		}
+	}
+	results, err := t.conn.ExecContext(ctx, sql)
+	t.conn.inTransaction = err == nil
+	if err == nil && t.isolation != "" {
+		t.previousIsolation = previousIsolation(results)
+	}
+	return err
+}
+
+//Level selected before the transaction, read committed (default) when it is unknown.
+func previousIsolation(results []*Result) string {
+	var level int
+	if len(results) > 0 && len(results[0].Rows) == 1 && convertAssign(&level, results[0].Rows[0][0]) == nil &&
+		level > 0 && level < len(sessionIsolationLevels) {
+		return sessionIsolationLevels[level]
+	}
+	return "read committed"
+}
+
+//isolation level stays set on the session after transaction ends, restore the previous one
+func (t *MssqlConnTx) end(sql string) error {
+	if t.previousIsolation != "" {
+		sql += "\nset transaction isolation level " + t.previousIsolation
+	}
+	_, err := t.conn.Exec(sql)
+	t.conn.inTransaction = false
 	return err
 }
 
 //implements Commit for Tx interface from http://golang.org/src/pkg/database/sql/driver/driver.go
 func (t *MssqlConnTx) Commit() error {
-	_, err := t.conn.Exec("commit transaction")
-	return err
+	return t.end("commit transaction")
 }
 
 //implements Rollback for Tx interface from http://golang.org/src/pkg/database/sql/driver/driver.go
 func (t *MssqlConnTx) Rollback() error {
-	_, err := t.conn.Exec("rollback transaction")
-	return err
+	return t.end("rollback transaction")
 }
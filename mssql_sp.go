@@ -0,0 +1,278 @@
+package freetds
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+//ReturnStatus receives return status of the stored procedure called through database/sql.
+//Pass pointer to it as one of the arguments.
+//
+//Example:
+//  var status freetds.ReturnStatus
+//  var total int
+//  _, err := db.Exec("exec dbo.calc", sql.Named("id", 1), sql.Named("total", sql.Out{Dest: &total}), &status)
+type ReturnStatus int
+
+var (
+	execSpRe       = regexp.MustCompile(`(?is)^\s*exec(?:ute)?\s+([\w\.\[\]#$]+)\s*;?\s*$`)
+	spIdentifierRe = regexp.MustCompile(`^\s*([\w\.\[\]#$]+)\s*;?\s*$`)
+	//@name, but not @@variable
+	namedPlaceholderRe = regexp.MustCompile(`(?:^|[^@\w])@[\pL_#]`)
+)
+
+//Statements which are the whole batch by themselves, not procedure names.
+var singleWordStatements = map[string]bool{
+	"begin": true, "commit": true, "rollback": true, "save": true,
+	"checkpoint": true, "reconfigure": true, "shutdown": true, "return": true,
+}
+
+//Reports whether query is a stored procedure call, and returns procedure name.
+//Query is a procedure call when it is "exec name" without placeholders,
+//or just a procedure name with arguments. Comments are ignored.
+func spCall(query string, args []driver.NamedValue) (string, bool) {
+	query = stripComments(query)
+	if m := execSpRe.FindStringSubmatch(query); m != nil {
+		return m[1], true
+	}
+	if name, ok := spIdentifier(query); ok && len(args) > 0 {
+		return name, true
+	}
+	return "", false
+}
+
+//Procedure name when query is a single identifier which is not a statement keyword.
+func spIdentifier(query string) (string, bool) {
+	m := spIdentifierRe.FindStringSubmatch(query)
+	if m == nil || singleWordStatements[strings.ToLower(m[1])] {
+		return "", false
+	}
+	return m[1], true
+}
+
+//Number of placeholders in the query, -1 for procedure calls and queries
+//which may have named placeholders, where driver checks the arguments.
+func numInput(query string) int {
+	code := stripComments(query)
+	if execSpRe.MatchString(code) || hasNamedPlaceholders(query) {
+		return -1
+	}
+	if _, ok := spIdentifier(code); ok {
+		return -1
+	}
+	_, n := query2Statement(query)
+	return n
+}
+
+//Returns query with comments replaced by spaces.
+func stripComments(query string) string {
+	var b strings.Builder
+	scanQuery(query, func(kind, start, end int) {
+		if kind == commentPart {
+			b.WriteByte(' ')
+			return
+		}
+		b.WriteString(query[start:end])
+	})
+	return b.String()
+}
+
+//Reports whether query has @name outside of string literals, quoted identifiers and comments.
+func hasNamedPlaceholders(query string) bool {
+	found := false
+	scanQuery(query, func(kind, start, end int) {
+		if kind == codePart && namedPlaceholderRe.MatchString(query[start:end]) {
+			found = true
+		}
+	})
+	return found
+}
+
+//Output argument of the procedure call.
+type spOut struct {
+	name string
+	dest interface{}
+}
+
+//Executes stored procedure with database/sql arguments.
+//Named arguments are matched to the procedure params by name, unnamed by position.
+//Params which are not given use the default values.
+func (conn *Conn) execSpNamedValues(ctx context.Context, spName string, args []driver.NamedValue) (*SpResult, error) {
+	spParams, err := conn.getSpParams(spName)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]interface{}, 0, len(spParams))
+	outs := make([]spOut, 0)
+	var status *ReturnStatus
+	position := 0
+	for _, arg := range args {
+		if rs, ok := arg.Value.(*ReturnStatus); ok {
+			status = rs
+			continue
+		}
+		idx := -1
+		if arg.Name == "" {
+			idx = position
+			position++
+		} else {
+			for i, p := range spParams {
+				if strings.EqualFold(strings.TrimPrefix(p.Name, "@"), strings.TrimPrefix(arg.Name, "@")) {
+					idx = i
+					break
+				}
+			}
+		}
+		if idx < 0 || idx >= len(spParams) {
+			return nil, fmt.Errorf("procedure %s has no param %s", spName, argName(arg))
+		}
+		value := arg.Value
+		if out, ok := value.(sql.Out); ok {
+			if !spParams[idx].IsOutput {
+				return nil, fmt.Errorf("param %s of procedure %s is not output param", spParams[idx].Name, spName)
+			}
+			outs = append(outs, spOut{name: spParams[idx].Name, dest: out.Dest})
+			value = nil
+			if out.In {
+				value = reflect.Indirect(reflect.ValueOf(out.Dest)).Interface()
+			}
+		}
+		for len(params) <= idx {
+			params = append(params, spDefault{})
+		}
+		params[idx] = value
+	}
+
+	result, err := conn.ExecSpContext(ctx, spName, params...)
+	if err != nil {
+		return nil, err
+	}
+	for _, out := range outs {
+		for _, p := range result.outputParams {
+			if strings.EqualFold(p.Name, out.name) {
+				if err := convertAssign(out.dest, p.Value); err != nil {
+					return nil, fmt.Errorf("output param %s: %s", out.name, err)
+				}
+				break
+			}
+		}
+	}
+	if status != nil {
+		*status = ReturnStatus(result.Status())
+	}
+	return result, nil
+}
+
+func argName(arg driver.NamedValue) string {
+	if arg.Name != "" {
+		return arg.Name
+	}
+	return fmt.Sprintf("at position %d", arg.Ordinal)
+}
+
+//Accepts values which are handled by the driver itself,
+//others are converted by database/sql.
+func checkNamedValue(nv *driver.NamedValue) error {
+	switch nv.Value.(type) {
+	case sql.Out, *ReturnStatus, Decimal, UniqueIdentifier, TVP, TypedParam:
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+//implements Result interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+//for stored procedure calls.
+type MssqlSpResult struct {
+	result *SpResult
+}
+
+func (r *MssqlSpResult) RowsAffected() (int64, error) {
+	var n int64
+	for _, result := range r.result.results {
+		n += int64(result.RowsAffected)
+	}
+	return n, nil
+}
+
+func (r *MssqlSpResult) LastInsertId() (int64, error) {
+	return 0, errors.New("no LastInsertId available")
+}
+
+//implements Rows interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+//for stored procedure calls.
+//Results of the procedure are read before output params, so they are buffered.
+type MssqlSpRows struct {
+	results []*Result
+	current int
+	row     int
+}
+
+func newMssqlSpRows(result *SpResult) *MssqlSpRows {
+	r := &MssqlSpRows{}
+	for _, res := range result.results {
+		if len(res.Columns) > 0 {
+			r.results = append(r.results, res)
+		}
+	}
+	return r
+}
+
+func (r *MssqlSpRows) Columns() []string {
+	if r.current >= len(r.results) {
+		return []string{}
+	}
+	columns := r.results[r.current].Columns
+	cols := make([]string, len(columns))
+	for i, c := range columns {
+		cols[i] = c.Name
+	}
+	return cols
+}
+
+func (r *MssqlSpRows) Close() error {
+	r.current = len(r.results)
+	return nil
+}
+
+func (r *MssqlSpRows) Next(dest []driver.Value) error {
+	if r.current >= len(r.results) || r.row >= len(r.results[r.current].Rows) {
+		return io.EOF
+	}
+	values := r.results[r.current].Rows[r.row]
+	r.row++
+	for i := range dest {
+		dest[i] = driverValue(values[i])
+	}
+	return nil
+}
+
+func (r *MssqlSpRows) HasNextResultSet() bool {
+	return r.current < len(r.results)-1
+}
+
+func (r *MssqlSpRows) NextResultSet() error {
+	if !r.HasNextResultSet() {
+		return io.EOF
+	}
+	r.current++
+	r.row = 0
+	return nil
+}
+
+//Converts value read from the server to valid driver.Value.
+func driverValue(value interface{}) driver.Value {
+	switch v := value.(type) {
+	case Decimal:
+		return v.String()
+	case UniqueIdentifier:
+		return v.String()
+	}
+	return value
+}
@@ -1,20 +1,43 @@
 package freetds
 
 import (
+	"context"
+	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"io"
+	"reflect"
+	"strings"
 )
 
 //implements Stmt interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+//Statement is prepared on the server with sp_prepare, handles are cached on the connection.
 type MssqlStmt struct {
 	query    string
 	numInput int
 	conn     *Conn
+	//keys of the prepared handles used by the statement
+	prepared map[string]bool
 }
 
+//Releases prepared handles of the statement.
 func (s *MssqlStmt) Close() error {
-	return nil
+	keys := make([]string, 0, len(s.prepared))
+	for key := range s.prepared {
+		keys = append(keys, key)
+	}
+	s.prepared = nil
+	return s.conn.releasePrepared(s, keys)
+}
+
+func (s *MssqlStmt) usePrepared(key string) {
+	if key == "" {
+		return
+	}
+	if s.prepared == nil {
+		s.prepared = make(map[string]bool)
+	}
+	s.prepared[key] = true
 }
 
 func (s *MssqlStmt) NumInput() int {
@@ -22,67 +45,178 @@ func (s *MssqlStmt) NumInput() int {
 }
 
 func (s *MssqlStmt) Exec(args []driver.Value) (driver.Result, error) {
-	results, err := s.conn.ExecuteSql(s.query, args...)
+	return s.exec(context.Background(), args)
+}
+
+func (s *MssqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.rows(context.Background(), args)
+}
+
+//implements ExecContext for StmtExecContext interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (s *MssqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if spName, ok := spCall(s.query, args); ok {
+		result, err := s.conn.execSpNamedValues(ctx, spName, args)
+		if err != nil {
+			return nil, err
+		}
+		return &MssqlSpResult{result: result}, nil
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.exec(ctx, values)
+}
+
+func (s *MssqlStmt) exec(ctx context.Context, values []driver.Value) (driver.Result, error) {
+	results, key, err := s.conn.executePrepared(ctx, s, s.query, values...)
+	s.usePrepared(key)
 	if err != nil {
 		return nil, err
 	}
 	return &MssqlResult{results: results}, nil
 }
 
-func (s *MssqlStmt) Query(args []driver.Value) (driver.Rows, error) {
-	results, err := s.conn.ExecuteSql(s.query, args...)
+//implements QueryContext for StmtQueryContext interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (s *MssqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if spName, ok := spCall(s.query, args); ok {
+		result, err := s.conn.execSpNamedValues(ctx, spName, args)
+		if err != nil {
+			return nil, err
+		}
+		return newMssqlSpRows(result), nil
+	}
+	values, err := namedValuesToValues(args)
 	if err != nil {
 		return nil, err
 	}
-	return &MssqlRows{results: results}, nil
+	return s.rows(ctx, values)
 }
 
-//implements Rows interface from http://golang.org/src/pkg/database/sql/driver/driver.go
-type MssqlRows struct {
-	results       []*Result
-	currentRow    int
-	currentResult int
+func (s *MssqlStmt) rows(ctx context.Context, values []driver.Value) (driver.Rows, error) {
+	rows, key, err := s.conn.queryPrepared(ctx, s, s.query, values...)
+	s.usePrepared(key)
+	if err != nil {
+		return nil, err
+	}
+	return &MssqlRows{rows: rows}, nil
+}
+
+//Converts named values to the list of values.
+//Named parameters are converted to sql.NamedArg.
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		switch arg.Value.(type) {
+		case sql.Out, *ReturnStatus:
+			return nil, errors.New("output params are supported only in stored procedure calls")
+		}
+		values[i] = arg.Value
+		if arg.Name != "" {
+			values[i] = sql.Named(arg.Name, arg.Value)
+		}
+	}
+	return values, nil
 }
 
-func (r MssqlRows) result() *Result {
-	return r.results[r.currentResult]
+//implements Rows interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+//Rows are streamed from the server, see Rows.
+type MssqlRows struct {
+	rows *Rows
 }
 
 func (r *MssqlRows) Columns() []string {
-	cols := make([]string, len(r.result().Columns))
-	for i, c := range r.result().Columns {
+	columns := r.rows.Columns()
+	cols := make([]string, len(columns))
+	for i, c := range columns {
 		cols[i] = c.Name
 	}
 	return cols
 }
 
 func (r *MssqlRows) Close() error {
-	return nil
+	return r.rows.Close()
 }
 
 func (r *MssqlRows) Next(dest []driver.Value) error {
-	if len(r.results) == 0 {
-		return io.EOF
-	}
-	if r.currentRow >= len(r.result().Rows) {
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		//database/sql closes rows after io.EOF when there is no next result set,
+		//errors raised by the rest of the batch are reported here
+		r.rows.lookAhead()
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
 		return io.EOF
 	}
 	for i, _ := range dest {
-		dest[i] = r.result().Rows[r.currentRow][i]
+		dest[i] = driverValue(r.rows.values[i])
 	}
-	r.currentRow++
 	return nil
 }
 
-// //true and move to next result if exists
-// func (r *MssqlRows) MoreResults() bool {
-// 	//last result is statusRow, because of that -2
-// 	if r.currentResult >= len(r.results) - 2 {
-// 		r.currentResult++
-// 		return true
-// 	}
-// 	return false
-// }
+//implements RowsColumnTypeDatabaseTypeName interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (r *MssqlRows) ColumnTypeDatabaseTypeName(index int) string {
+	return strings.ToUpper(r.rows.Columns()[index].TypeName)
+}
+
+//implements RowsColumnTypeLength interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (r *MssqlRows) ColumnTypeLength(index int) (int64, bool) {
+	c := r.rows.Columns()[index]
+	if !c.hasLength() {
+		return 0, false
+	}
+	return c.length(), true
+}
+
+//implements RowsColumnTypeNullable interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+//Nullability is unknown when column info couldn't be read.
+func (r *MssqlRows) ColumnTypeNullable(index int) (bool, bool) {
+	c := r.rows.Columns()[index]
+	if !c.hasColInfo {
+		return false, false
+	}
+	return c.Nullable, true
+}
+
+//implements RowsColumnTypePrecisionScale interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (r *MssqlRows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	c := r.rows.Columns()[index]
+	if c.DbType != SYBDECIMAL && c.DbType != SYBNUMERIC {
+		return 0, 0, false
+	}
+	return int64(c.Precision), int64(c.Scale), true
+}
+
+//implements RowsColumnTypeScanType interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (r *MssqlRows) ColumnTypeScanType(index int) reflect.Type {
+	return r.rows.Columns()[index].scanType()
+}
+
+//implements HasNextResultSet for RowsNextResultSet interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+//Next result set is read ahead when rows run out, before that true is returned until the batch is done.
+func (r *MssqlRows) HasNextResultSet() bool {
+	if r.rows.err != nil {
+		return false
+	}
+	if r.rows.ahead {
+		return r.rows.next != nil
+	}
+	return !r.rows.done
+}
+
+//implements NextResultSet for RowsNextResultSet interface from http://golang.org/src/pkg/database/sql/driver/driver.go
+func (r *MssqlRows) NextResultSet() error {
+	if !r.rows.NextResultSet() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	return nil
+}
 
 //implements Result interface from http://golang.org/src/pkg/database/sql/driver/driver.go
 type MssqlResult struct {
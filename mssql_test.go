@@ -1,10 +1,14 @@
 package freetds
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"os"
@@ -91,6 +95,26 @@ func TestGoSqlPrepareQuery(t *testing.T) {
 	testRingers(t, rows)
 }
 
+func TestGoSqlPreparedStatementReuse(t *testing.T) {
+	db, _, _ := open(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	_, err := db.Exec("if object_id('tempdb..#prepared') is null create table #prepared (id int)")
+	assert.Nil(t, err)
+	stmt, err := db.Prepare("insert into #prepared values (?)")
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		result, err := stmt.Exec(i)
+		assert.Nil(t, err)
+		n, _ := result.RowsAffected()
+		assert.EqualValues(t, 1, n)
+	}
+	assert.Nil(t, stmt.Close())
+	var count int
+	assert.Nil(t, db.QueryRow("select count(*) from #prepared").Scan(&count))
+	assert.Equal(t, 10, count)
+}
+
 func TestLastInsertIdRowsAffected(t *testing.T) {
 	db, _, sybase125 := open(t)
 	defer db.Close()
@@ -240,3 +264,385 @@ func TestBlobs(t *testing.T) {
 	assert.Equal(t, strWant, strGot)
 	assert.Equal(t, want, got)
 }
+
+func TestMssqlConnImplementsContextInterfaces(t *testing.T) {
+	var c interface{} = &MssqlConn{}
+	_, ok := c.(driver.QueryerContext)
+	assert.True(t, ok)
+	_, ok = c.(driver.ExecerContext)
+	assert.True(t, ok)
+	_, ok = c.(driver.ConnPrepareContext)
+	assert.True(t, ok)
+	_, ok = c.(driver.ConnBeginTx)
+	assert.True(t, ok)
+	_, ok = c.(driver.Pinger)
+	assert.True(t, ok)
+	_, ok = c.(driver.SessionResetter)
+	assert.True(t, ok)
+	_, ok = c.(driver.Validator)
+	assert.True(t, ok)
+	_, ok = c.(driver.NamedValueChecker)
+	assert.True(t, ok)
+	var s interface{} = &MssqlStmt{}
+	_, ok = s.(driver.StmtQueryContext)
+	assert.True(t, ok)
+	_, ok = s.(driver.StmtExecContext)
+	assert.True(t, ok)
+	var sr interface{} = &MssqlSpRows{}
+	_, ok = sr.(driver.RowsNextResultSet)
+	assert.True(t, ok)
+	var r interface{} = &MssqlRows{}
+	_, ok = r.(driver.RowsNextResultSet)
+	assert.True(t, ok)
+	_, ok = r.(driver.RowsColumnTypeDatabaseTypeName)
+	assert.True(t, ok)
+	_, ok = r.(driver.RowsColumnTypeLength)
+	assert.True(t, ok)
+	_, ok = r.(driver.RowsColumnTypeNullable)
+	assert.True(t, ok)
+	_, ok = r.(driver.RowsColumnTypePrecisionScale)
+	assert.True(t, ok)
+	_, ok = r.(driver.RowsColumnTypeScanType)
+	assert.True(t, ok)
+}
+
+func TestMssqlRowsColumnTypeNullable(t *testing.T) {
+	result := NewResult()
+	result.Columns = []*ResultColumn{{Name: "a", Nullable: true, hasColInfo: true}, {Name: "b"}}
+	r := &MssqlRows{rows: &Rows{result: result}}
+	nullable, ok := r.ColumnTypeNullable(0)
+	assert.True(t, nullable)
+	assert.True(t, ok)
+	nullable, ok = r.ColumnTypeNullable(1)
+	assert.False(t, nullable)
+	assert.False(t, ok)
+}
+
+func TestIsolationLevel(t *testing.T) {
+	level, err := isolationLevel(sql.LevelDefault)
+	assert.Nil(t, err)
+	assert.Equal(t, "", level)
+	level, err = isolationLevel(sql.LevelSerializable)
+	assert.Nil(t, err)
+	assert.Equal(t, "serializable", level)
+	level, err = isolationLevel(sql.LevelSnapshot)
+	assert.Nil(t, err)
+	assert.Equal(t, "snapshot", level)
+	_, err = isolationLevel(sql.LevelLinearizable)
+	assert.NotNil(t, err)
+}
+
+func TestNamedValuesToValues(t *testing.T) {
+	values, err := namedValuesToValues([]driver.NamedValue{{Ordinal: 1, Value: 1}, {Ordinal: 2, Value: "pero"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []driver.Value{1, "pero"}, values)
+	values, err = namedValuesToValues([]driver.NamedValue{{Name: "id", Ordinal: 1, Value: 1}})
+	assert.Nil(t, err)
+	assert.Equal(t, []driver.Value{sql.Named("id", 1)}, values)
+	var out int
+	_, err = namedValuesToValues([]driver.NamedValue{{Name: "id", Ordinal: 1, Value: sql.Out{Dest: &out}}})
+	assert.NotNil(t, err)
+}
+
+func TestGoSqlNamedParams(t *testing.T) {
+	db, _, sybase125 := open(t)
+	defer db.Close()
+	if sybase125 {
+		t.Skip("named params are not supported in Sybase 12.5")
+	}
+	var lname string
+	err := db.QueryRow("select au_lname from authors where au_id = @id and (@id is not null)", sql.Named("id", "172-32-1176")).Scan(&lname)
+	assert.Nil(t, err)
+	assert.Equal(t, "White", lname)
+
+	stmt, err := db.Prepare("select au_lname from authors where au_id = @id")
+	assert.Nil(t, err)
+	defer stmt.Close()
+	err = stmt.QueryRow(sql.Named("id", "172-32-1176")).Scan(&lname)
+	assert.Nil(t, err)
+	assert.Equal(t, "White", lname)
+}
+
+func TestGoSqlBeginTxIsolationLevel(t *testing.T) {
+	db, _, sybase125 := open(t)
+	defer db.Close()
+	if sybase125 {
+		t.Skip("transaction_isolation_level is not available in Sybase 12.5")
+	}
+	db.SetMaxOpenConns(1)
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+	assert.Nil(t, err)
+	var level int
+	err = tx.QueryRow("select transaction_isolation_level from sys.dm_exec_sessions where session_id = @@spid").Scan(&level)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, level)
+	assert.Nil(t, tx.Commit())
+	//default level is restored after commit
+	err = db.QueryRow("select transaction_isolation_level from sys.dm_exec_sessions where session_id = @@spid").Scan(&level)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, level)
+
+	//level set in sql before the transaction is restored
+	conn, err := db.Conn(context.Background())
+	assert.Nil(t, err)
+	_, err = conn.ExecContext(context.Background(), "set transaction isolation level repeatable read")
+	assert.Nil(t, err)
+	tx, err = conn.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Rollback())
+	err = conn.QueryRowContext(context.Background(), "select transaction_isolation_level from sys.dm_exec_sessions where session_id = @@spid").Scan(&level)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, level)
+	_, err = conn.ExecContext(context.Background(), "set transaction isolation level read committed")
+	assert.Nil(t, err)
+	assert.Nil(t, conn.Close())
+
+	_, err = db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	assert.NotNil(t, err)
+}
+
+func TestPreviousIsolation(t *testing.T) {
+	results := []*Result{{Rows: [][]interface{}{{int16(4)}}}}
+	assert.Equal(t, "serializable", previousIsolation(results))
+	assert.Equal(t, "read committed", previousIsolation(nil))
+	results = []*Result{{Rows: [][]interface{}{{int16(0)}}}}
+	assert.Equal(t, "read committed", previousIsolation(results))
+}
+
+func TestGoSqlQueryContextTimeout(t *testing.T) {
+	db, _, _ := open(t)
+	defer db.Close()
+	assert.Nil(t, db.PingContext(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := db.QueryContext(ctx, "waitfor delay '00:00:10'")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestGoSqlQueryError(t *testing.T) {
+	db, _, _ := open(t)
+	defer db.Close()
+	rows, err := db.Query("select 1/0")
+	assert.Nil(t, err)
+	defer rows.Close()
+	assert.False(t, rows.Next())
+	assert.NotNil(t, rows.Err())
+}
+
+func TestGoSqlRowsClosedAfterLastResultSet(t *testing.T) {
+	db, _, _ := open(t)
+	defer db.Close()
+	rows, err := db.Query("select au_id from authors; insert into #no_table values (1)")
+	assert.Nil(t, err)
+	for rows.Next() {
+	}
+	//error of the last statement is reported
+	assert.NotNil(t, rows.Err())
+
+	rows, err = db.Query("select au_id from authors; select 1 where 1 = 0; update authors set au_id = au_id where 1 = 0")
+	assert.Nil(t, err)
+	for rows.Next() {
+	}
+	assert.Nil(t, rows.Err())
+	assert.True(t, rows.NextResultSet())
+	assert.False(t, rows.Next())
+	//rows are closed without calling Close, connection is released
+	assert.False(t, rows.NextResultSet())
+	assert.Equal(t, 0, db.Stats().InUse)
+}
+
+func TestGoSqlNextResultSet(t *testing.T) {
+	db, _, _ := open(t)
+	defer db.Close()
+	rows, err := db.Query("select au_id from authors where au_id = ?; select 1 one, 2 two", "172-32-1176")
+	assert.Nil(t, err)
+	defer rows.Close()
+	var id string
+	assert.True(t, rows.Next())
+	assert.Nil(t, rows.Scan(&id))
+	assert.Equal(t, "172-32-1176", id)
+	assert.False(t, rows.Next())
+
+	assert.True(t, rows.NextResultSet())
+	cols, err := rows.Columns()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"one", "two"}, cols)
+	var one, two int
+	assert.True(t, rows.Next())
+	assert.Nil(t, rows.Scan(&one, &two))
+	assert.Equal(t, 2, two)
+	assert.False(t, rows.Next())
+
+	//status row is not visible as result set
+	assert.False(t, rows.NextResultSet())
+	assert.Nil(t, rows.Err())
+}
+
+func TestGoSqlColumnTypes(t *testing.T) {
+	db, _, _ := open(t)
+	defer db.Close()
+	rows, err := db.Query("select au_id, au_lname, cast(1.5 as decimal(10, 2)) amount, 1 one from authors")
+	assert.Nil(t, err)
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(types))
+
+	assert.Equal(t, "VARCHAR", types[1].DatabaseTypeName())
+	length, ok := types[1].Length()
+	assert.True(t, ok)
+	assert.EqualValues(t, 40, length)
+	nullable, ok := types[1].Nullable()
+	assert.True(t, ok)
+	assert.False(t, nullable)
+
+	assert.Equal(t, "DECIMAL", types[2].DatabaseTypeName())
+	precision, scale, ok := types[2].DecimalSize()
+	assert.True(t, ok)
+	assert.EqualValues(t, 10, precision)
+	assert.EqualValues(t, 2, scale)
+
+	assert.Equal(t, "INT", types[3].DatabaseTypeName())
+	assert.Equal(t, reflect.TypeOf(int32(0)), types[3].ScanType())
+	_, ok = types[3].Length()
+	assert.False(t, ok)
+}
+
+func TestSpCall(t *testing.T) {
+	args := []driver.NamedValue{{Ordinal: 1, Value: 1}}
+	name, ok := spCall("exec dbo.test_sp", nil)
+	assert.True(t, ok)
+	assert.Equal(t, "dbo.test_sp", name)
+	name, ok = spCall("  EXECUTE [dbo].[test_sp];", nil)
+	assert.True(t, ok)
+	assert.Equal(t, "[dbo].[test_sp]", name)
+	name, ok = spCall("test_sp", args)
+	assert.True(t, ok)
+	assert.Equal(t, "test_sp", name)
+	_, ok = spCall("test_sp", nil)
+	assert.False(t, ok)
+	_, ok = spCall("exec test_sp ?", args)
+	assert.False(t, ok)
+	_, ok = spCall("select * from authors", args)
+	assert.False(t, ok)
+
+	name, ok = spCall("/* call */ exec test_sp -- no args", nil)
+	assert.True(t, ok)
+	assert.Equal(t, "test_sp", name)
+	_, ok = spCall("commit", args)
+	assert.False(t, ok)
+
+	assert.Equal(t, -1, numInput("exec test_sp"))
+	assert.Equal(t, 2, numInput("select ?, ?"))
+	assert.Equal(t, -1, numInput("select @id"))
+	assert.Equal(t, 1, numInput("select @@rowcount, ?"))
+	assert.Equal(t, 1, numInput("select 'me@example.com', ? -- @id"))
+	assert.Equal(t, 0, numInput("select [@id] /* @id */"))
+	assert.Equal(t, 0, numInput("checkpoint"))
+}
+
+func TestCheckNamedValue(t *testing.T) {
+	var status ReturnStatus
+	var out int
+	for _, v := range []interface{}{sql.Out{Dest: &out}, &status, Decimal{}, UniqueIdentifier{}, TVP{}} {
+		assert.Nil(t, checkNamedValue(&driver.NamedValue{Value: v}))
+	}
+	assert.Equal(t, driver.ErrSkip, checkNamedValue(&driver.NamedValue{Value: 1}))
+	assert.Equal(t, driver.ErrSkip, checkNamedValue(&driver.NamedValue{Value: &out}))
+}
+
+func TestMssqlSpRows(t *testing.T) {
+	one := NewResult()
+	one.addColumn("one", 0, SYBINT4)
+	one.addValue(0, 0, int32(1))
+	empty := NewResult()
+	two := NewResult()
+	two.addColumn("two", 0, SYBINT4)
+	two.addValue(0, 0, int32(2))
+	rows := newMssqlSpRows(&SpResult{results: []*Result{one, empty, two}})
+
+	assert.Equal(t, []string{"one"}, rows.Columns())
+	dest := make([]driver.Value, 1)
+	assert.Nil(t, rows.Next(dest))
+	assert.Equal(t, int32(1), dest[0])
+	assert.NotNil(t, rows.Next(dest))
+	assert.True(t, rows.HasNextResultSet())
+	assert.Nil(t, rows.NextResultSet())
+	assert.Equal(t, []string{"two"}, rows.Columns())
+	assert.Nil(t, rows.Next(dest))
+	assert.Equal(t, int32(2), dest[0])
+	assert.False(t, rows.HasNextResultSet())
+	assert.NotNil(t, rows.NextResultSet())
+}
+
+func TestGoSqlExecSpOutputParams(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	err := createProcedure(conn, "test_go_sql_output_params", `
+    @p1 int, @p2 int = 2, @sum int output, @name varchar(10) output
+  as
+    select @sum = @p1 + @p2, @name = @name + 'ro'
+    select @sum sum
+    return 42`)
+	assert.Nil(t, err)
+	db, _, _ := open(t)
+	defer db.Close()
+
+	var status ReturnStatus
+	var sum int
+	name := "pe"
+	_, err = db.Exec("exec test_go_sql_output_params",
+		sql.Named("p1", 1),
+		sql.Named("sum", sql.Out{Dest: &sum}),
+		sql.Named("name", sql.Out{Dest: &name, In: true}),
+		&status)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, sum)
+	assert.Equal(t, "pero", name)
+	assert.Equal(t, ReturnStatus(42), status)
+
+	//positional params and result set
+	row := db.QueryRow("test_go_sql_output_params", 10, 20, sql.Named("sum", sql.Out{Dest: &sum}))
+	var selected int
+	assert.Nil(t, row.Scan(&selected))
+	assert.Equal(t, 30, selected)
+	assert.Equal(t, 30, sum)
+}
+
+func TestGoSqlExecSpTVPWithDefaultParam(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	if conn.sybaseMode() || conn.sybaseMode125() {
+		t.Skip("table-valued params do not exist in Sybase")
+	}
+	_, err := conn.Exec(`
+	if exists(select * from sys.procedures where name = 'test_go_sql_tvp_default')
+	  drop procedure test_go_sql_tvp_default
+	if exists(select * from sys.types where name = 'test_go_sql_id_list')
+	  drop type test_go_sql_id_list`)
+	assert.Nil(t, err)
+	_, err = conn.Exec("create type test_go_sql_id_list as table (id int)")
+	assert.Nil(t, err)
+	err = createProcedure(conn, "test_go_sql_tvp_default", `
+    @ids test_go_sql_id_list readonly, @offset int = 100, @min int, @cnt int output
+  as
+    select @cnt = count(*) from @ids where id + @offset >= @min`)
+	assert.Nil(t, err)
+	db, _, _ := open(t)
+	defer db.Close()
+
+	tvp, _ := NewTVP("", []int{1, 2, 3})
+	var cnt int
+	//@offset is skipped and uses the default value
+	_, err = db.Exec("exec test_go_sql_tvp_default",
+		sql.Named("ids", tvp),
+		sql.Named("min", 102),
+		sql.Named("cnt", sql.Out{Dest: &cnt}))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, cnt)
+}
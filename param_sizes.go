@@ -0,0 +1,155 @@
+package freetds
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+//Values of the param_sizes connection string option.
+//They control how strings and binary params of ExecuteSql are declared.
+const (
+	//Declare params with exact length of the value, default.
+	paramSizesExact = "exact"
+	//Round lengths up to 32, 256, 4000 (8000 for binary) or max,
+	//so the same query has only a few distinct params definitions in the plan cache.
+	paramSizesBucket = "bucket"
+	//Declare strings with the type of the column they are compared with or inserted into,
+	//found by sp_describe_undeclared_parameters and cached by statement. Other params are bucketed.
+	paramSizesSchema = "schema"
+)
+
+var (
+	nvarcharBuckets  = []int{32, 256, maxNCharSize}
+	varbinaryBuckets = []int{32, 256, maxCharSize}
+)
+
+//Returns the smallest bucket which fits length, 0 (max) if none.
+func bucket(length int, buckets []int) int {
+	for _, b := range buckets {
+		if length <= b {
+			return b
+		}
+	}
+	return 0
+}
+
+//Replaces string and binary params with typed params, sized according to the param_sizes mode.
+func (conn *Conn) sizeParams(mode string, statement string, params []driver.Value) []driver.Value {
+	if mode != paramSizesBucket && mode != paramSizesSchema {
+		return params
+	}
+	var types map[string]string
+	if mode == paramSizesSchema {
+		types = conn.describeParams(statement, params)
+	}
+	sized := make([]driver.Value, len(params))
+	position := 0
+	for i, param := range params {
+		var name string
+		named, isNamed := param.(sql.NamedArg)
+		if isNamed {
+			name = "@" + strings.TrimPrefix(named.Name, "@")
+			param = named.Value
+		} else {
+			position++
+			name = fmt.Sprintf("@p%d", position)
+		}
+		param = sizeParam(param, types[strings.ToLower(name)])
+		if isNamed {
+			named.Value = param
+			param = named
+		}
+		sized[i] = param
+	}
+	return sized
+}
+
+func sizeParam(value driver.Value, columnType string) driver.Value {
+	switch v := value.(type) {
+	case string:
+		if p, ok := columnTypedParam(v, columnType); ok {
+			return p
+		}
+		return NVarChar(v, bucket(len(utf16.Encode([]rune(v))), nvarcharBuckets))
+	case []byte:
+		return VarBinary(v, bucket(len(v), varbinaryBuckets))
+	}
+	return value
+}
+
+var charTypeRe = regexp.MustCompile(`^(n?)(?:var)?char\((\d+|max)\)$`)
+
+//Declares string param with the type of the column.
+//Fails if type is not char type or the value doesn't fit into it,
+//or column is not unicode and value has non ASCII characters.
+func columnTypedParam(s string, columnType string) (TypedParam, bool) {
+	m := charTypeRe.FindStringSubmatch(columnType)
+	if m == nil {
+		return TypedParam{}, false
+	}
+	unicode := m[1] == "n"
+	length := len(utf16.Encode([]rune(s)))
+	if !unicode {
+		for _, r := range s {
+			if r > 127 {
+				return TypedParam{}, false
+			}
+		}
+		length = len(s)
+	}
+	if size, err := strconv.Atoi(m[2]); err == nil && length > size {
+		return TypedParam{}, false
+	}
+	p := TypedParam{Type: columnType, Value: s, literal: fmt.Sprintf("'%s'", quote(s))}
+	if unicode {
+		p.literal = "N" + p.literal
+	}
+	return p, true
+}
+
+//Finds types of the statement params from the schema.
+//Returns map of lowercase param names to type declarations.
+func (conn *Conn) describeParams(statement string, params []driver.Value) map[string]string {
+	if types, ok := conn.paramTypes.Get(statement); ok {
+		return types
+	}
+	for _, param := range params {
+		if _, ok := param.(tvpVariable); ok {
+			//table variables are declared outside of the statement
+			return nil
+		}
+	}
+	//failed describe would doom transaction with xact_abort on, so it is skipped in transactions
+	results, err := conn.Exec(fmt.Sprintf("if @@trancount = 0\n  exec sp_describe_undeclared_parameters N'%s'", quote(statement)))
+	types := make(map[string]string)
+	if err == nil && len(results) == 0 {
+		//in transaction, try again later
+		return types
+	}
+	if err == nil {
+		var name, typ interface{}
+		for _, row := range results[0].Rows {
+			for i, c := range results[0].Columns {
+				switch c.Name {
+				case "name":
+					name = row[i]
+				case "suggested_system_type_name":
+					typ = row[i]
+				}
+			}
+			if n, ok := name.(string); ok {
+				if t, ok := typ.(string); ok {
+					types[strings.ToLower(n)] = t
+				}
+			}
+		}
+	}
+	//statements which can't be described are cached too, so they are not described again
+	conn.paramTypes.Set(statement, types)
+	return types
+}
@@ -0,0 +1,125 @@
+package freetds
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucket(t *testing.T) {
+	assert.Equal(t, 32, bucket(0, nvarcharBuckets))
+	assert.Equal(t, 32, bucket(32, nvarcharBuckets))
+	assert.Equal(t, 256, bucket(33, nvarcharBuckets))
+	assert.Equal(t, 4000, bucket(4000, nvarcharBuckets))
+	assert.Equal(t, 0, bucket(4001, nvarcharBuckets))
+	assert.Equal(t, 8000, bucket(4001, varbinaryBuckets))
+}
+
+func TestColumnTypedParam(t *testing.T) {
+	p, ok := columnTypedParam("172-32-1176", "varchar(11)")
+	assert.True(t, ok)
+	assert.Equal(t, "varchar(11)", p.Type)
+	assert.Equal(t, "'172-32-1176'", p.literal)
+	p, ok = columnTypedParam("žaba", "nchar(4)")
+	assert.True(t, ok)
+	assert.Equal(t, "N'žaba'", p.literal)
+	_, ok = columnTypedParam("pero", "varchar(max)")
+	assert.True(t, ok)
+
+	//doesn't fit
+	_, ok = columnTypedParam("172-32-11760", "varchar(11)")
+	assert.False(t, ok)
+	//non ASCII into non unicode column
+	_, ok = columnTypedParam("žaba", "varchar(10)")
+	assert.False(t, ok)
+	_, ok = columnTypedParam("1", "int")
+	assert.False(t, ok)
+	_, ok = columnTypedParam("1", "")
+	assert.False(t, ok)
+}
+
+func TestSqlStatementParamSizes(t *testing.T) {
+	conn := &Conn{credentials: credentials{paramSizes: paramSizesBucket}}
+	s, err := conn.sqlStatement("select ?, ?, @name, ?", paramSizesBucket, false, "pero", []byte{1, 2}, sql.Named("name", strings.Repeat("x", 300)), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "@p1 nvarchar(32), @p2 varbinary(32), @name nvarchar(4000), @p3 int", s.paramDef)
+	assert.Equal(t, "@p1=N'pero', @p2=0x0102, @name=N'"+strings.Repeat("x", 300)+"', @p3=1", s.paramVal)
+
+	conn = &Conn{credentials: credentials{}}
+	s, err = conn.sqlStatement("select ?", "", false, "pero")
+	assert.Nil(t, err)
+	assert.Equal(t, "@p1 nvarchar (4)", s.paramDef)
+}
+
+func TestSizeParamsSchema(t *testing.T) {
+	conn := &Conn{credentials: credentials{paramSizes: paramSizesSchema}, paramTypes: newParamTypesCache()}
+	statement := "select * from authors where au_id = @p1 and au_lname = @name"
+	conn.paramTypes.Set(statement, map[string]string{"@p1": "varchar(11)", "@name": "varchar(40)"})
+	params := conn.sizeParams(paramSizesSchema, statement, []driver.Value{"172-32-1176", sql.Named("Name", "White")})
+	assert.Equal(t, "varchar(11)", params[0].(TypedParam).Type)
+	assert.Equal(t, "varchar(40)", params[1].(sql.NamedArg).Value.(TypedParam).Type)
+
+	//value which doesn't fit is bucketed
+	params = conn.sizeParams(paramSizesSchema, statement, []driver.Value{"172-32-11760", sql.Named("name", "White")})
+	assert.Equal(t, "nvarchar(32)", params[0].(TypedParam).Type)
+}
+
+func TestParamTypesCacheLRU(t *testing.T) {
+	pc := newParamTypesCache()
+	pc.size = 2
+	pc.Set("a", map[string]string{"@p1": "int"})
+	pc.Set("b", nil)
+	_, ok := pc.Get("a")
+	assert.True(t, ok)
+	//b is least recently used
+	pc.Set("c", nil)
+	_, ok = pc.Get("b")
+	assert.False(t, ok)
+	types, ok := pc.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "int", types["@p1"])
+	pc.Set("a", map[string]string{"@p1": "bigint"})
+	types, _ = pc.Get("a")
+	assert.Equal(t, "bigint", types["@p1"])
+	assert.Equal(t, 2, pc.lru.Len())
+}
+
+func TestParseConnectionStringParamSizes(t *testing.T) {
+	crd := NewCredentials("host=myServerAddress;param_sizes=Bucket")
+	assert.Equal(t, paramSizesBucket, crd.paramSizes)
+	crd = NewCredentials("host=myServerAddress;Param Sizes=schema")
+	assert.Equal(t, paramSizesSchema, crd.paramSizes)
+}
+
+func TestExecuteSqlParamSizesSchema(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	if conn.sybaseMode125() {
+		t.Skip("sp_describe_undeclared_parameters is not available in Sybase 12.5")
+	}
+	conn.credentials.paramSizes = paramSizesSchema
+	query := "select au_lname from authors where au_id = ?"
+	results, err := conn.ExecuteSql(query, "172-32-1176")
+	assert.Nil(t, err)
+	assert.Equal(t, "White", results[0].Rows[0][0])
+	statement, _ := query2Statement(query)
+	types, ok := conn.paramTypes.Get(statement)
+	assert.True(t, ok)
+	assert.Equal(t, "varchar(11)", types["@p1"])
+
+	//quoted literal in the statement
+	query = "select au_lname from authors where au_lname = 'White' and au_id = ?"
+	results, err = conn.ExecuteSql(query, "172-32-1176")
+	assert.Nil(t, err)
+	assert.Equal(t, "White", results[0].Rows[0][0])
+	statement, _ = query2Statement(query)
+	types, ok = conn.paramTypes.Get(statement)
+	assert.True(t, ok)
+	assert.Equal(t, "varchar(11)", types["@p1"])
+}
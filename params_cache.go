@@ -1,6 +1,7 @@
 package freetds
 
 import (
+	"container/list"
 	"sync"
 )
 
@@ -27,3 +28,55 @@ func NewParamsCache() *ParamsCache {
 		cache: make(map[string][]*spParam),
 	}
 }
+
+//Max number of statements in the params types cache.
+const paramTypesCacheSize = 1000
+
+//Types of the sp_executesql params found from schema, by statement.
+//Statements are ad-hoc sql, so the cache is LRU bounded to paramTypesCacheSize.
+type paramTypesCache struct {
+	size  int
+	lru   *list.List
+	cache map[string]*list.Element
+	sync.Mutex
+}
+
+type paramTypes struct {
+	statement string
+	types     map[string]string
+}
+
+func (pc *paramTypesCache) Get(statement string) (map[string]string, bool) {
+	pc.Lock()
+	defer pc.Unlock()
+	e, found := pc.cache[statement]
+	if !found {
+		return nil, false
+	}
+	pc.lru.MoveToFront(e)
+	return e.Value.(*paramTypes).types, true
+}
+
+//Sets types of the statement, least recently used statement is removed when the cache is full.
+func (pc *paramTypesCache) Set(statement string, types map[string]string) {
+	pc.Lock()
+	defer pc.Unlock()
+	if e, found := pc.cache[statement]; found {
+		e.Value.(*paramTypes).types = types
+		pc.lru.MoveToFront(e)
+		return
+	}
+	pc.cache[statement] = pc.lru.PushFront(&paramTypes{statement: statement, types: types})
+	for pc.lru.Len() > pc.size {
+		last := pc.lru.Remove(pc.lru.Back()).(*paramTypes)
+		delete(pc.cache, last.statement)
+	}
+}
+
+func newParamTypesCache() *paramTypesCache {
+	return &paramTypesCache{
+		size:  paramTypesCacheSize,
+		lru:   list.New(),
+		cache: make(map[string]*list.Element),
+	}
+}
@@ -0,0 +1,133 @@
+package freetds
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//Kinds of the query parts, see scanQuery.
+const (
+	codePart = iota
+	//string literal, N'' included, or quoted identifier
+	quotedPart
+	commentPart
+)
+
+//Splits query into code, quoted and comment parts and calls visit for each non empty part,
+//with offsets of its first character and the character after it.
+func scanQuery(query string, visit func(kind, start, end int)) {
+	start := 0
+	for i := 0; i < len(query); i++ {
+		var kind, last int
+		switch c := query[i]; {
+		case c == '\'' || c == '"':
+			//closing quote is escaped by doubling
+			kind, last = quotedPart, skipQuoted(query, i, c)
+		case c == '[':
+			kind, last = quotedPart, skipQuoted(query, i, ']')
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			kind, last = commentPart, len(query)
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				last = i + end - 1
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			kind, last = commentPart, skipComment(query, i)
+		default:
+			continue
+		}
+		if last >= len(query) {
+			last = len(query) - 1
+		}
+		if start < i {
+			visit(codePart, start, i)
+		}
+		visit(kind, i, last+1)
+		i = last
+		start = last + 1
+	}
+	if start < len(query) {
+		visit(codePart, start, len(query))
+	}
+}
+
+//Returns offsets of ? placeholders in query.
+//Question marks in string literals, quoted identifiers and comments are not placeholders.
+func placeholders(query string) []int {
+	offsets := make([]int, 0)
+	scanQuery(query, func(kind, start, end int) {
+		if kind != codePart {
+			return
+		}
+		for i := start; i < end; i++ {
+			if query[i] == '?' {
+				offsets = append(offsets, i)
+			}
+		}
+	})
+	return offsets
+}
+
+//Returns offset of the closing quote of the literal starting at i.
+//Unterminated literal lasts until the end of the query.
+func skipQuoted(query string, i int, quote byte) int {
+	for i++; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+		return i
+	}
+	return len(query)
+}
+
+//Returns offset of the last character of the block comment starting at i.
+//Block comments can be nested.
+func skipComment(query string, i int) int {
+	depth := 0
+	for ; i < len(query); i++ {
+		switch {
+		case strings.HasPrefix(query[i:], "/*"):
+			depth++
+			i++
+		case strings.HasPrefix(query[i:], "*/"):
+			depth--
+			i++
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(query)
+}
+
+//Replaces placeholders in query with values returned by replace.
+func replacePlaceholders(query string, offsets []int, replace func(i int) string) string {
+	statement := ""
+	last := 0
+	for i, offset := range offsets {
+		statement += query[last:offset] + replace(i)
+		last = offset + 1
+	}
+	return statement + query[last:]
+}
+
+//Describes mismatch between the number of placeholders in query and number of params.
+func paramsCountError(query string, offsets []int, numParams int) error {
+	msg := fmt.Sprintf("Incorrect number of params, expecting %d got %d", len(offsets), numParams)
+	if numParams < len(offsets) {
+		line, column := position(query, offsets[numParams])
+		msg += fmt.Sprintf(", placeholder %d at line %d column %d has no value", numParams+1, line, column)
+	}
+	return errors.New(msg)
+}
+
+//Line and column, both starting from 1, of the offset in query.
+func position(query string, offset int) (int, int) {
+	line := strings.Count(query[:offset], "\n") + 1
+	column := offset - strings.LastIndex(query[:offset], "\n")
+	return line, column
+}
@@ -0,0 +1,41 @@
+package freetds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceholders(t *testing.T) {
+	assert.Equal(t, []int{7, 10}, placeholders("select ?, ?"))
+	assert.Equal(t, []int{}, placeholders("select 'why?'"))
+	assert.Equal(t, []int{}, placeholders("select N'it''s?'"))
+	assert.Equal(t, []int{19}, placeholders("select 'it''s?' a, ?"))
+	assert.Equal(t, []int{}, placeholders(`select 1 "why?"`))
+	assert.Equal(t, []int{}, placeholders("select 1 [why?]"))
+	assert.Equal(t, []int{22}, placeholders("select 1 [a]]?] where ?"))
+	assert.Equal(t, []int{23}, placeholders("select 1 -- why?\nwhere ?"))
+	assert.Equal(t, []int{}, placeholders("select 1 -- why?"))
+	assert.Equal(t, []int{38}, placeholders("select 1 /* why? /* nested? */ ? */ , ?"))
+	assert.Equal(t, []int{}, placeholders("select 1 /* unterminated ?"))
+	assert.Equal(t, []int{}, placeholders("select 'unterminated ?"))
+	assert.Equal(t, []int{56}, placeholders("select * from urls where url like 'http://%?%' and id = ?"))
+	assert.Equal(t, []int{9}, placeholders("select 1-?"))
+	assert.Equal(t, []int{9}, placeholders("select 1/?"))
+}
+
+func TestReplacePlaceholders(t *testing.T) {
+	query := "select ?, '?', ?"
+	statement := replacePlaceholders(query, placeholders(query), func(i int) string {
+		return []string{"a", "b"}[i]
+	})
+	assert.Equal(t, "select a, '?', b", statement)
+}
+
+func TestParamsCountError(t *testing.T) {
+	query := "select ?,\n  ?"
+	err := paramsCountError(query, placeholders(query), 1)
+	assert.Equal(t, "Incorrect number of params, expecting 2 got 1, placeholder 2 at line 2 column 3 has no value", err.Error())
+	err = paramsCountError(query, placeholders(query), 3)
+	assert.Equal(t, "Incorrect number of params, expecting 2 got 3", err.Error())
+}
@@ -0,0 +1,237 @@
+package freetds
+
+import (
+	"container/list"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//Max number of prepared statement handles kept open on the connection.
+const preparedCacheSize = 100
+
+//Server message when sp_execute is called with unknown handle,
+//e.g. after the connection was reset.
+const msgPreparedNotFound = 8179
+
+//Prepared statement handle.
+type preparedHandle struct {
+	key    string
+	handle int64
+	//statements using the handle, it is released when all of them are closed
+	owners map[interface{}]bool
+}
+
+//LRU cache of the prepared statement handles on the connection.
+//Key is statement text with params definition.
+//Handles live in the server session, so the cache is cleared when the connection is closed.
+type preparedCache struct {
+	size    int
+	lru     *list.List
+	handles map[string]*list.Element
+}
+
+func newPreparedCache(size int) *preparedCache {
+	return &preparedCache{
+		size:    size,
+		lru:     list.New(),
+		handles: make(map[string]*list.Element),
+	}
+}
+
+//Returns handle for the key and marks it as recently used.
+func (pc *preparedCache) get(key string) (*preparedHandle, bool) {
+	e, ok := pc.handles[key]
+	if !ok {
+		return nil, false
+	}
+	pc.lru.MoveToFront(e)
+	return e.Value.(*preparedHandle), true
+}
+
+//Adds handle to the cache.
+//Returns least recently used handles which are evicted and should be unprepared.
+func (pc *preparedCache) add(h *preparedHandle) []*preparedHandle {
+	pc.handles[h.key] = pc.lru.PushFront(h)
+	evicted := make([]*preparedHandle, 0)
+	for pc.lru.Len() > pc.size {
+		evicted = append(evicted, pc.remove(pc.lru.Back().Value.(*preparedHandle).key))
+	}
+	return evicted
+}
+
+//Removes handle from the cache and returns it, or nil if there is no handle for the key.
+func (pc *preparedCache) remove(key string) *preparedHandle {
+	e, ok := pc.handles[key]
+	if !ok {
+		return nil
+	}
+	pc.lru.Remove(e)
+	delete(pc.handles, key)
+	return e.Value.(*preparedHandle)
+}
+
+func (pc *preparedCache) len() int {
+	return pc.lru.Len()
+}
+
+func (conn *Conn) preparedHandles() *preparedCache {
+	if conn.prepared == nil {
+		conn.prepared = newPreparedCache(preparedCacheSize)
+	}
+	return conn.prepared
+}
+
+//Returns sql batch which executes query with params as prepared statement.
+//Statement is prepared on the first use with sp_prepare and its handle is cached on the connection.
+//Owner is registered as user of the handle, see releasePrepared.
+func (conn *Conn) preparedSql(owner interface{}, query string, withStatusRow bool, params ...driver.Value) (string, string, error) {
+	if conn.sybaseMode125() {
+		sql, err := executeSqlSybase125Statement(query, withStatusRow, params...)
+		return sql, "", err
+	}
+	//exact sizes would prepare the statement again for each distinct length of string and binary params
+	paramSizes := conn.credentials.paramSizes
+	if paramSizes != paramSizesSchema {
+		paramSizes = paramSizesBucket
+	}
+	s, err := conn.sqlStatement(query, paramSizes, withStatusRow, params...)
+	if err != nil {
+		return "", "", err
+	}
+	key := s.paramDef + "\n" + s.statement
+	h, err := conn.prepare(key, s)
+	if err != nil {
+		return "", "", err
+	}
+	h.owners[owner] = true
+	sql := fmt.Sprintf("exec sp_execute %d", h.handle)
+	if s.paramVal != "" {
+		sql += ", " + s.paramVal
+	}
+	return s.declare + sql, key, nil
+}
+
+//Returns cached handle of the statement or prepares it.
+//Handles evicted from the cache are unprepared.
+//When that fails the new handle is removed from the cache too, it has no owners
+//so releasePrepared would never unprepare it. It is released with the session.
+func (conn *Conn) prepare(key string, s *sqlStatement) (*preparedHandle, error) {
+	cache := conn.preparedHandles()
+	if h, ok := cache.get(key); ok {
+		return h, nil
+	}
+	paramDef := "null"
+	if s.paramDef != "" {
+		paramDef = fmt.Sprintf("N'%s'", s.paramDef)
+	}
+	sql := fmt.Sprintf("declare @handle int\nexec sp_prepare @handle output, %s, N'%s'\nselect @handle [handle]", paramDef, s.statement)
+	results, err := conn.Exec(sql)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 || len(results[len(results)-1].Rows) != 1 {
+		return nil, errors.New("sp_prepare didn't return handle")
+	}
+	var handle int64
+	if err := convertAssign(&handle, results[len(results)-1].Rows[0][0]); err != nil {
+		return nil, err
+	}
+	h := &preparedHandle{key: key, handle: handle, owners: make(map[interface{}]bool)}
+	if evicted := cache.add(h); len(evicted) > 0 {
+		if err := conn.unprepare(evicted); err != nil {
+			cache.remove(key)
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+//Releases handles used by the owner.
+//Handle is unprepared when the last of its owners releases it.
+func (conn *Conn) releasePrepared(owner interface{}, keys []string) error {
+	if conn.prepared == nil {
+		return nil
+	}
+	released := make([]*preparedHandle, 0)
+	for _, key := range keys {
+		h, ok := conn.prepared.get(key)
+		if !ok {
+			continue
+		}
+		delete(h.owners, owner)
+		if len(h.owners) == 0 {
+			released = append(released, conn.prepared.remove(key))
+		}
+	}
+	if conn.isDead() {
+		//handles are gone with the session
+		return nil
+	}
+	return conn.unprepare(released)
+}
+
+func (conn *Conn) unprepare(handles []*preparedHandle) error {
+	if len(handles) == 0 {
+		return nil
+	}
+	sql := make([]string, len(handles))
+	for i, h := range handles {
+		sql[i] = fmt.Sprintf("exec sp_unprepare %d", h.handle)
+	}
+	_, err := conn.exec(strings.Join(sql, "\n"))
+	return err
+}
+
+//Removes handle which server doesn't know any more.
+//Reports whether execution should be retried.
+func (conn *Conn) preparedNotFound(key string) bool {
+	if key == "" || conn.HasMessageNumber(msgPreparedNotFound) == 0 {
+		return false
+	}
+	conn.preparedHandles().remove(key)
+	return true
+}
+
+//Executes query with params as prepared statement, same as ExecuteSqlContext.
+func (conn *Conn) executePrepared(ctx context.Context, owner interface{}, query string, params ...driver.Value) ([]*Result, string, error) {
+	var results []*Result
+	var key string
+	err := conn.withContext(ctx, func() error {
+		for retry := true; ; retry = false {
+			var sql string
+			var err error
+			sql, key, err = conn.preparedSql(owner, query, true, params...)
+			if err != nil {
+				return err
+			}
+			results, err = conn.Exec(sql)
+			if err != nil && retry && conn.preparedNotFound(key) {
+				continue
+			}
+			results = skipInsertResults(results, tvpInserts(params))
+			return err
+		}
+	})
+	return results, key, err
+}
+
+//Executes query with params as prepared statement, same as QueryContext.
+func (conn *Conn) queryPrepared(ctx context.Context, owner interface{}, query string, params ...driver.Value) (*Rows, string, error) {
+	for retry := true; ; retry = false {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		sql, key, err := conn.preparedSql(owner, query, false, params...)
+		if err != nil {
+			return nil, "", err
+		}
+		rows, err := conn.querySql(ctx, sql)
+		if err != nil && retry && conn.preparedNotFound(key) {
+			continue
+		}
+		return rows, key, err
+	}
+}
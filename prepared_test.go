@@ -0,0 +1,111 @@
+package freetds
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreparedCache(t *testing.T) {
+	pc := newPreparedCache(2)
+	assert.Equal(t, 0, len(pc.add(&preparedHandle{key: "a", handle: 1})))
+	assert.Equal(t, 0, len(pc.add(&preparedHandle{key: "b", handle: 2})))
+	h, ok := pc.get("a")
+	assert.True(t, ok)
+	assert.EqualValues(t, 1, h.handle)
+
+	//b is least recently used
+	evicted := pc.add(&preparedHandle{key: "c", handle: 3})
+	assert.Equal(t, 1, len(evicted))
+	assert.Equal(t, "b", evicted[0].key)
+	_, ok = pc.get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 2, pc.len())
+
+	assert.Equal(t, "a", pc.remove("a").key)
+	assert.Nil(t, pc.remove("a"))
+	assert.Equal(t, 1, pc.len())
+}
+
+func TestExecutePrepared(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	if conn.sybaseMode125() {
+		t.Skip("prepared statements are not used in Sybase 12.5")
+	}
+	owner := &struct{}{}
+	query := "select au_lname from authors where au_id = ?"
+	for i := 0; i < 3; i++ {
+		results, key, err := conn.executePrepared(context.Background(), owner, query, "172-32-1176")
+		assert.Nil(t, err)
+		assert.NotEqual(t, "", key)
+		assert.Equal(t, "White", results[0].Rows[0][0])
+	}
+	//params of different length are declared with the same size
+	_, _, err := conn.executePrepared(context.Background(), owner, query, "172-32-117")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, conn.preparedHandles().len())
+
+	rows, key, err := conn.queryPrepared(context.Background(), owner, query, "172-32-1176")
+	assert.Nil(t, err)
+	assert.True(t, rows.Next())
+	assert.Nil(t, rows.Close())
+	//different params definition, status row is not included
+	assert.Equal(t, 2, conn.preparedHandles().len())
+
+	assert.Nil(t, conn.releasePrepared(owner, []string{key}))
+	assert.Equal(t, 1, conn.preparedHandles().len())
+}
+
+func TestExecutePreparedHandleNotFound(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	if conn.sybaseMode125() {
+		t.Skip("prepared statements are not used in Sybase 12.5")
+	}
+	owner := &struct{}{}
+	_, key, err := conn.executePrepared(context.Background(), owner, "select ?", 1)
+	assert.Nil(t, err)
+	h, _ := conn.preparedHandles().get(key)
+	//handle is unprepared behind the cache
+	_, err = conn.Exec(fmt.Sprintf("exec sp_unprepare %d", h.handle))
+	assert.Nil(t, err)
+	results, _, err := conn.executePrepared(context.Background(), owner, "select ?", 1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, results[0].Rows[0][0])
+}
+
+func TestPrepareUnprepareEvictedFails(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	if conn.sybaseMode125() {
+		t.Skip("prepared statements are not used in Sybase 12.5")
+	}
+	conn.prepared = newPreparedCache(1)
+	owner := &struct{}{}
+	_, key, err := conn.executePrepared(context.Background(), owner, "select ?", 1)
+	assert.Nil(t, err)
+	h, _ := conn.preparedHandles().get(key)
+	//evicted handle is unprepared behind the cache, so unprepare fails
+	_, err = conn.Exec(fmt.Sprintf("exec sp_unprepare %d", h.handle))
+	assert.Nil(t, err)
+	_, _, err = conn.executePrepared(context.Background(), owner, "select ?, ?", 1, 2)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, conn.preparedHandles().len())
+
+	results, _, err := conn.executePrepared(context.Background(), owner, "select ?, ?", 1, 2)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, results[0].Rows[0][1])
+	assert.Equal(t, 1, conn.preparedHandles().len())
+}
@@ -1,8 +1,144 @@
 package freetds
 
+import (
+	"reflect"
+	"time"
+)
+
 type ResultColumn struct {
 	Name   string
 	DbSize int
 	DbType int
 	Type   string
+
+	//Sql type name, e.g. varchar or decimal.
+	//Without length, unicode and non unicode char types are not distinguished.
+	TypeName string
+	//Column allows NULL values.
+	Nullable bool
+	//Precision and scale of decimal and numeric columns.
+	Precision int
+	Scale     int
+	//Identity column.
+	Identity bool
+	//Name of the table column comes from, empty for expressions.
+	Table string
+	//Nullable, Identity and Table are read from the server, false when dbcolinfo failed.
+	hasColInfo bool
+}
+
+//Sql type name for the db-lib column type.
+func sqlTypeName(typ int, varLength bool) string {
+	switch typ {
+	case SYBINT1:
+		return "tinyint"
+	case SYBINT2:
+		return "smallint"
+	case SYBINT4:
+		return "int"
+	case SYBINT8:
+		return "bigint"
+	case SYBREAL:
+		return "real"
+	case SYBFLT8:
+		return "float"
+	case SYBBIT, SYBBITN:
+		return "bit"
+	case SYBMONEY4:
+		return "smallmoney"
+	case SYBMONEY:
+		return "money"
+	case SYBDATETIME:
+		return "datetime"
+	case SYBDATETIME4:
+		return "smalldatetime"
+	case SYBMSDATE:
+		return "date"
+	case SYBMSTIME:
+		return "time"
+	case SYBMSDATETIME2:
+		return "datetime2"
+	case SYBMSDATETIMEOFFSET:
+		return "datetimeoffset"
+	case SYBDECIMAL:
+		return "decimal"
+	case SYBNUMERIC:
+		return "numeric"
+	case SYBUNIQUE:
+		return "uniqueidentifier"
+	case SYBCHAR:
+		if varLength {
+			return "varchar"
+		}
+		return "char"
+	case SYBVARCHAR, XSYBVARCHAR:
+		return "varchar"
+	case SYBNVARCHAR, XSYBNVARCHAR:
+		return "nvarchar"
+	case XSYBNCHAR:
+		return "nchar"
+	case SYBTEXT:
+		return "text"
+	case SYBNTEXT:
+		return "ntext"
+	case XSYBXML:
+		return "xml"
+	case SYBIMAGE:
+		return "image"
+	case SYBBINARY:
+		if varLength {
+			return "varbinary"
+		}
+		return "binary"
+	case SYBVARBINARY, XSYBVARBINARY:
+		return "varbinary"
+	}
+	return ""
+}
+
+//Go type of the values in the column, as returned by the mssql driver.
+func (c *ResultColumn) scanType() reflect.Type {
+	switch c.DbType {
+	case SYBINT1:
+		return reflect.TypeOf(uint8(0))
+	case SYBINT2:
+		return reflect.TypeOf(int16(0))
+	case SYBINT4:
+		return reflect.TypeOf(int32(0))
+	case SYBINT8:
+		return reflect.TypeOf(int64(0))
+	case SYBREAL:
+		return reflect.TypeOf(float32(0))
+	case SYBFLT8, SYBMONEY, SYBMONEY4:
+		return reflect.TypeOf(float64(0))
+	case SYBBIT, SYBBITN:
+		return reflect.TypeOf(false)
+	case SYBDATETIME, SYBDATETIME4, SYBMSDATE, SYBMSTIME, SYBMSDATETIME2, SYBMSDATETIMEOFFSET:
+		return reflect.TypeOf(time.Time{})
+	case SYBIMAGE, SYBBINARY, SYBVARBINARY, XSYBVARBINARY:
+		return reflect.TypeOf([]byte{})
+	}
+	//decimal and uniqueidentifier are returned as string
+	return reflect.TypeOf("")
+}
+
+//Has variable length, reported by database/sql ColumnTypeLength.
+func (c *ResultColumn) hasLength() bool {
+	switch c.DbType {
+	case SYBCHAR, SYBVARCHAR, XSYBVARCHAR, SYBNVARCHAR, XSYBNVARCHAR, XSYBNCHAR,
+		SYBTEXT, SYBNTEXT, XSYBXML,
+		SYBIMAGE, SYBBINARY, SYBVARBINARY, XSYBVARBINARY:
+		return true
+	}
+	return false
+}
+
+//Length in characters for char types, in bytes for binary types.
+//DbSize of unicode types is in bytes, two per character.
+func (c *ResultColumn) length() int64 {
+	switch c.DbType {
+	case SYBNVARCHAR, XSYBNVARCHAR, XSYBNCHAR, SYBNTEXT:
+		return int64(c.DbSize / 2)
+	}
+	return int64(c.DbSize)
 }
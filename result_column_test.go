@@ -0,0 +1,29 @@
+package freetds
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSqlTypeName(t *testing.T) {
+	assert.Equal(t, "int", sqlTypeName(SYBINT4, false))
+	assert.Equal(t, "varchar", sqlTypeName(SYBCHAR, true))
+	assert.Equal(t, "char", sqlTypeName(SYBCHAR, false))
+	assert.Equal(t, "varbinary", sqlTypeName(SYBBINARY, true))
+	assert.Equal(t, "decimal", sqlTypeName(SYBDECIMAL, false))
+	assert.Equal(t, "datetime2", sqlTypeName(SYBMSDATETIME2, false))
+	assert.Equal(t, "", sqlTypeName(-1, false))
+}
+
+func TestResultColumnScanType(t *testing.T) {
+	assert.Equal(t, reflect.TypeOf(int32(0)), (&ResultColumn{DbType: SYBINT4}).scanType())
+	assert.Equal(t, reflect.TypeOf(time.Time{}), (&ResultColumn{DbType: SYBDATETIME}).scanType())
+	assert.Equal(t, reflect.TypeOf(""), (&ResultColumn{DbType: SYBDECIMAL}).scanType())
+	assert.True(t, (&ResultColumn{DbType: SYBCHAR}).hasLength())
+	assert.False(t, (&ResultColumn{DbType: SYBINT4}).hasLength())
+	assert.EqualValues(t, 20, (&ResultColumn{DbType: XSYBNVARCHAR, DbSize: 40}).length())
+	assert.EqualValues(t, 40, (&ResultColumn{DbType: XSYBVARCHAR, DbSize: 40}).length())
+}
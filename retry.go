@@ -0,0 +1,58 @@
+package freetds
+
+import (
+	"time"
+)
+
+//RetryPolicy controls retrying of the failed database calls.
+//
+//Set it on the pool to retry ConnPool.Do and ConnPool.DoInTransaction,
+//or on the connection to retry Conn.ExecSp.
+//
+//Example:
+//  pool.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, Backoff: 100 * time.Millisecond})
+type RetryPolicy struct {
+	//Max number of attempts, including the first one.
+	MaxAttempts int
+	//Wait before the first retry, doubled for each next retry.
+	Backoff time.Duration
+	//Upper limit for the wait between retries, no limit if 0.
+	MaxBackoff time.Duration
+	//Decides whether the error is retryable, IsRetryable is used when nil.
+	Retryable func(error) bool
+}
+
+//IsRetryable reports errors which will probably succeed on the next attempt:
+//deadlock victims, lock timeouts, lost connections and mirror failover.
+func IsRetryable(err error) bool {
+	return IsDeadlock(err) ||
+		IsLockTimeout(err) ||
+		IsConnectionLost(err) ||
+		IsMirrorFailover(err)
+}
+
+//Calls fn until it succeeds, returns not retryable error or MaxAttempts is reached.
+//Nil policy calls fn once.
+func (rp *RetryPolicy) run(fn func() error) error {
+	if rp == nil {
+		return fn()
+	}
+	retryable := rp.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	backoff := rp.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= rp.MaxAttempts || !retryable(err) {
+			return err
+		}
+		if backoff > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+			if rp.MaxBackoff > 0 && backoff > rp.MaxBackoff {
+				backoff = rp.MaxBackoff
+			}
+		}
+	}
+}
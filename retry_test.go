@@ -0,0 +1,69 @@
+package freetds
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func deadlockError() error {
+	e := &Error{}
+	e.addMessage(ServerMessage{Number: 1205, Severity: 13})
+	return e.withText("deadlock")
+}
+
+func TestRetryPolicyNil(t *testing.T) {
+	var rp *RetryPolicy
+	calls := 0
+	err := rp.run(func() error {
+		calls++
+		return deadlockError()
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicyRetriesRetryableErrors(t *testing.T) {
+	rp := &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	calls := 0
+	err := rp.run(func() error {
+		calls++
+		return deadlockError()
+	})
+	assert.True(t, IsDeadlock(err))
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = rp.run(func() error {
+		calls++
+		if calls < 2 {
+			return deadlockError()
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRetryPolicyStopsOnOtherErrors(t *testing.T) {
+	rp := &RetryPolicy{MaxAttempts: 3}
+	calls := 0
+	err := rp.run(func() error {
+		calls++
+		return errors.New("handler error")
+	})
+	assert.EqualError(t, err, "handler error")
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicyCustomRetryable(t *testing.T) {
+	rp := &RetryPolicy{MaxAttempts: 2, Retryable: func(err error) bool { return true }}
+	calls := 0
+	rp.run(func() error {
+		calls++
+		return errors.New("handler error")
+	})
+	assert.Equal(t, 2, calls)
+}
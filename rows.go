@@ -0,0 +1,244 @@
+package freetds
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"unsafe"
+)
+
+/*
+#include <stdlib.h>
+#include <sybfront.h>
+#include <sybdb.h>
+*/
+import "C"
+
+//Rows is a cursor over the query results.
+//Rows are read from the server one by one, as Next is called, instead of
+//being buffered in memory like in the results of Exec.
+//
+//Connection can't be used for other queries until Rows are closed.
+//
+//Example:
+//  rows, err := conn.Query("select au_id, au_lname from authors where state = ?", "CA")
+//  ...
+//  defer rows.Close()
+//  for rows.Next() {
+//    var id, name string
+//    err = rows.Scan(&id, &name)
+//  }
+//  err = rows.Err()
+type Rows struct {
+	conn    *Conn
+	result  *Result
+	columns []column
+	values  []interface{}
+	//all results are read, nothing is pending on the connection
+	done bool
+	err  error
+	ctx  context.Context
+	stop func() bool
+	//next result set, read ahead when rows of the current one ran out
+	ahead       bool
+	next        *Result
+	nextColumns []column
+}
+
+//Execute sql query and return cursor positioned at the first result set.
+//? in query are arguments placeholders, same as in ExecuteSql.
+func (conn *Conn) Query(query string, params ...driver.Value) (*Rows, error) {
+	return conn.QueryContext(context.Background(), query, params...)
+}
+
+//Same as Query but the running query is interrupted when ctx is done.
+//Ctx is watched until Rows are closed.
+func (conn *Conn) QueryContext(ctx context.Context, query string, params ...driver.Value) (*Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sql, err := conn.executeSqlStatement(query, false, params...)
+	if err != nil {
+		return nil, err
+	}
+	return conn.querySql(ctx, sql)
+}
+
+//Executes sql batch and returns cursor positioned at the first result set.
+func (conn *Conn) querySql(ctx context.Context, sql string) (*Rows, error) {
+	if conn.isDead() || conn.isMirrorSlave() {
+		if err := conn.reconnect(); err != nil {
+			return nil, err
+		}
+	}
+	r := &Rows{conn: conn, ctx: ctx, stop: conn.watch(ctx)}
+	if err := r.exec(sql); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Rows) exec(sql string) error {
+	conn := r.conn
+	conn.clearMessages()
+
+	cmd := C.CString(sql)
+	defer C.free(unsafe.Pointer(cmd))
+
+	if C.dbcmd(conn.dbproc, cmd) == C.FAIL {
+		r.fail(conn.raiseError("dbcmd failed"))
+		return r.err
+	}
+	if C.dbsqlexec(conn.dbproc) == C.FAIL {
+		r.fail(conn.raiseError("dbsqlexec failed"))
+		return r.err
+	}
+	r.nextResult()
+	return r.err
+}
+
+//Moves to the next result set which has columns.
+//Result sets without columns (e.g. from insert or update statements) are skipped.
+func (r *Rows) nextResult() bool {
+	r.lookAhead()
+	r.result, r.columns = r.next, r.nextColumns
+	r.values = nil
+	r.ahead, r.next, r.nextColumns = false, nil, nil
+	r.conn.currentResult = r.result
+	return r.result != nil
+}
+
+//Reads the next result set which has columns, without moving to it.
+//Reports whether there is one. Remaining rows of the current result set can't be read after it.
+func (r *Rows) lookAhead() bool {
+	if r.ahead {
+		return r.next != nil
+	}
+	if r.done || r.err != nil {
+		return false
+	}
+	r.ahead = true
+	conn := r.conn
+	for {
+		erc := C.dbresults(conn.dbproc)
+		if erc == C.NO_MORE_RESULTS {
+			r.done = true
+			if len(conn.Error) > 0 {
+				r.fail(conn.raise(nil))
+			}
+			return false
+		}
+		if erc == C.FAIL {
+			r.fail(conn.raise(errors.New("dbresults failed")))
+			return false
+		}
+		if C.dbnumcols(conn.dbproc) == 0 {
+			continue
+		}
+		result := NewResult()
+		columns, err := conn.bindColumns(result)
+		if err != nil {
+			r.fail(err)
+			return false
+		}
+		r.next = result
+		r.nextColumns = columns
+		return true
+	}
+}
+
+//Stops iteration with the error.
+//Rest of the batch may still be pending, it is canceled by Close.
+func (r *Rows) fail(err error) {
+	if ctxErr := r.ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	r.err = err
+}
+
+//Columns of the current result set.
+func (r *Rows) Columns() []*ResultColumn {
+	if r.result == nil {
+		return nil
+	}
+	return r.result.Columns
+}
+
+//Advances to the next row of the current result set.
+//Returns false when there are no more rows or on error, check Err to distinguish.
+func (r *Rows) Next() bool {
+	r.values = nil
+	if r.done || r.err != nil || r.ahead || r.result == nil {
+		return false
+	}
+	values, err := r.conn.nextRow(r.columns)
+	if err != nil {
+		r.fail(r.conn.raise(err))
+		return false
+	}
+	if values == nil {
+		//error raised after the columns metadata, e.g. conversion error in one of the rows
+		if len(r.conn.Error) > 0 {
+			r.fail(r.conn.raise(nil))
+		}
+		return false
+	}
+	r.values = values
+	return true
+}
+
+//Skips remaining rows of the current result set and moves to the next one.
+//Returns false when there are no more result sets.
+func (r *Rows) NextResultSet() bool {
+	if r.err != nil || (r.done && !r.ahead) {
+		return false
+	}
+	if r.result != nil {
+		for r.Next() {
+		}
+		if r.err != nil {
+			return false
+		}
+	}
+	return r.nextResult()
+}
+
+//Scan copies the columns in the current row into the values pointed at by dest.
+//Scan into struct is supported in the same way as in Result.Scan.
+func (r *Rows) Scan(dest ...interface{}) error {
+	if r.values == nil {
+		return errors.New("Scan called without calling Next.")
+	}
+	r.result.Rows = [][]interface{}{r.values}
+	r.result.currentRow = 0
+	return r.result.Scan(dest...)
+}
+
+//Error encountered during iteration.
+func (r *Rows) Err() error {
+	return r.err
+}
+
+//Close cancels the rest of the batch and releases the connection for other queries.
+//Returns the error of the iteration, failed cancel or done ctx.
+func (r *Rows) Close() error {
+	if r.conn == nil {
+		return nil
+	}
+	conn := r.conn
+	r.conn = nil
+	if !r.done {
+		r.done = true
+		conn.cancel()
+		if r.err == nil && conn.isDead() {
+			r.err = conn.raiseError("dbcancel failed, connection closed")
+		}
+	}
+	conn.currentResult = nil
+	if r.stop() && r.err == nil {
+		conn.cancel()
+		r.err = r.ctx.Err()
+	}
+	return r.err
+}
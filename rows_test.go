@@ -0,0 +1,130 @@
+package freetds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRowsScanWithoutNext(t *testing.T) {
+	r := &Rows{result: testResult(), done: true}
+	var i int
+	assert.NotNil(t, r.Scan(&i))
+	assert.False(t, r.Next())
+	assert.False(t, r.NextResultSet())
+}
+
+func TestRowsScan(t *testing.T) {
+	r := &Rows{result: testResult(), values: []interface{}{int32(1), "two"}}
+	var i int
+	var s string
+	assert.Nil(t, r.Scan(&i, &s))
+	assert.Equal(t, 1, i)
+	assert.Equal(t, "two", s)
+}
+
+func TestQuery(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query("select au_id, au_lname from authors where au_id = ?", "172-32-1176")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rows.Columns()))
+	assert.Equal(t, "au_lname", rows.Columns()[1].Name)
+	cnt := 0
+	for rows.Next() {
+		var id, name string
+		assert.Nil(t, rows.Scan(&id, &name))
+		assert.Equal(t, "White", name)
+		cnt++
+	}
+	assert.Nil(t, rows.Err())
+	assert.Equal(t, 1, cnt)
+	assert.False(t, rows.NextResultSet())
+	assert.Nil(t, rows.Close())
+}
+
+func TestQueryNextResultSet(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query("select au_id from authors; select 1 one, 2 two")
+	assert.Nil(t, err)
+	//skip authors without reading them
+	assert.True(t, rows.NextResultSet())
+	assert.Equal(t, 2, len(rows.Columns()))
+	assert.True(t, rows.Next())
+	var one, two int
+	assert.Nil(t, rows.Scan(&one, &two))
+	assert.Equal(t, 1, one)
+	assert.Equal(t, 2, two)
+	assert.False(t, rows.Next())
+	assert.False(t, rows.NextResultSet())
+	assert.Nil(t, rows.Err())
+	assert.Nil(t, rows.Close())
+}
+
+func TestQueryCloseCancelsBatch(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query("select * from authors; select * from authors")
+	assert.Nil(t, err)
+	assert.True(t, rows.Next())
+	assert.Nil(t, rows.Close())
+	assert.False(t, rows.Next())
+
+	//connection is usable after rows are closed
+	val, err := conn.SelectValue("select 1")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, val)
+}
+
+func TestQueryErrorAfterColumns(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query("select 1/0 [div]; select * from authors")
+	assert.Nil(t, err)
+	assert.False(t, rows.Next())
+	assert.NotNil(t, rows.Err())
+	assert.False(t, rows.NextResultSet())
+	assert.Equal(t, rows.Err(), rows.Close())
+
+	//rest of the batch is canceled, connection is usable
+	val, err := conn.SelectValue("select 1")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, val)
+}
+
+func TestQueryScanStruct(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+
+	type author struct {
+		AuId    string
+		AuLname string
+	}
+	rows, err := conn.Query("select au_id, au_lname from authors order by au_id")
+	assert.Nil(t, err)
+	defer rows.Close()
+	assert.True(t, rows.Next())
+	a := &author{}
+	assert.Nil(t, rows.Scan(a))
+	assert.Equal(t, "172-32-1176", a.AuId)
+}
@@ -0,0 +1,298 @@
+package freetds
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+//Max number of rows in the single insert values list.
+const tvpRowsPerInsert = 1000
+
+//TVP is table-valued parameter for ExecSp and ExecuteSql.
+//
+//FreeTDS can't send table-valued params through rpc, so the call is executed as
+//sql batch: table variable is declared, filled with rows and passed to the procedure.
+//
+//Example:
+//  ids := freetds.TVP{TypeName: "dbo.IdList", Rows: [][]interface{}{{1}, {2}, {3}}}
+//  rst, err := conn.ExecSp("sp_get_orders", ids)
+//  results, err := conn.ExecuteSql("select * from orders where id in (select id from ?)", ids)
+type TVP struct {
+	//Name of the table type, e.g. dbo.IdList.
+	//Required for ExecuteSql, for ExecSp it is read from the procedure params.
+	TypeName string
+	//Column names. Rows are inserted in the table type column order when empty.
+	Columns []string
+	//Row values, in the order of Columns.
+	Rows [][]interface{}
+}
+
+//NewTVP creates table-valued param from slice of structs or scalar values.
+//Exported struct fields are used as row values, in the order of table type columns.
+func NewTVP(typeName string, rows interface{}) (TVP, error) {
+	tvp := TVP{TypeName: typeName}
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return tvp, fmt.Errorf("rows should be slice, got %T", rows)
+	}
+	for i := 0; i < v.Len(); i++ {
+		row := reflect.Indirect(v.Index(i))
+		if row.Kind() != reflect.Struct || isScalarStruct(row.Type()) {
+			tvp.Rows = append(tvp.Rows, []interface{}{row.Interface()})
+			continue
+		}
+		values := make([]interface{}, 0, row.NumField())
+		for j := 0; j < row.NumField(); j++ {
+			if row.Type().Field(j).PkgPath != "" {
+				//unexported
+				continue
+			}
+			values = append(values, row.Field(j).Interface())
+		}
+		tvp.Rows = append(tvp.Rows, values)
+	}
+	return tvp, nil
+}
+
+//Structs which are single values, not rows.
+func isScalarStruct(t reflect.Type) bool {
+	return t == reflect.TypeOf(time.Time{}) || t == reflect.TypeOf(Decimal{})
+}
+
+//Returns sql which declares table variable of typeName and inserts rows into it.
+func (t TVP) declare(variable, typeName string) (string, error) {
+	if typeName == "" {
+		return "", errors.New("TVP type name is required")
+	}
+	sql := fmt.Sprintf("declare %s %s\n", variable, quoteName(typeName))
+	columns := ""
+	if len(t.Columns) > 0 {
+		names := make([]string, len(t.Columns))
+		for i, c := range t.Columns {
+			names[i] = "[" + strings.Replace(c, "]", "]]", -1) + "]"
+		}
+		columns = " (" + strings.Join(names, ", ") + ")"
+	}
+	for i := 0; i < len(t.Rows); i += tvpRowsPerInsert {
+		end := i + tvpRowsPerInsert
+		if end > len(t.Rows) {
+			end = len(t.Rows)
+		}
+		rows := make([]string, 0, end-i)
+		for _, row := range t.Rows[i:end] {
+			values := make([]string, len(row))
+			for j, value := range row {
+				literal, err := sqlLiteral(value)
+				if err != nil {
+					return "", err
+				}
+				values[j] = literal
+			}
+			rows = append(rows, "("+strings.Join(values, ", ")+")")
+		}
+		sql += fmt.Sprintf("insert into %s%s values\n%s\n", variable, columns, strings.Join(rows, ",\n"))
+	}
+	return sql, nil
+}
+
+//Table variable passed to sp_executesql in place of TVP param.
+type tvpVariable struct {
+	name     string
+	typeName string
+}
+
+//Replaces TVP params with table variables.
+//Returns sql which declares and fills variables.
+func declareTVPs(params []driver.Value) (string, []driver.Value, error) {
+	declare := ""
+	replaced := make([]driver.Value, len(params))
+	for i, param := range params {
+		replaced[i] = param
+		named, isNamed := param.(sql.NamedArg)
+		if isNamed {
+			param = named.Value
+		}
+		tvp, ok := param.(TVP)
+		if !ok {
+			continue
+		}
+		v := tvpVariable{name: fmt.Sprintf("@tvp%d", i+1), typeName: quoteName(tvp.TypeName)}
+		sql, err := tvp.declare(v.name, tvp.TypeName)
+		if err != nil {
+			return "", nil, err
+		}
+		declare += sql
+		replaced[i] = v
+		if isNamed {
+			named.Value = v
+			replaced[i] = named
+		}
+	}
+	return declare, replaced, nil
+}
+
+//Number of insert statements executed to fill table variables for params.
+func tvpInserts(params []driver.Value) int {
+	n := 0
+	for _, param := range params {
+		if named, ok := param.(sql.NamedArg); ok {
+			param = named.Value
+		}
+		if tvp, ok := param.(TVP); ok {
+			n += tvp.inserts()
+		}
+	}
+	return n
+}
+
+func (t TVP) inserts() int {
+	return (len(t.Rows) + tvpRowsPerInsert - 1) / tvpRowsPerInsert
+}
+
+//Removes up to n leading results without columns, returned by the inserts into table variables.
+func skipInsertResults(results []*Result, n int) []*Result {
+	for ; n > 0 && len(results) > 0 && len(results[0].Columns) == 0; n-- {
+		results = results[1:]
+	}
+	return results
+}
+
+func hasTVP(params []interface{}) bool {
+	for _, param := range params {
+		if named, ok := param.(sql.NamedArg); ok {
+			param = named.Value
+		}
+		if _, ok := param.(TVP); ok {
+			return true
+		}
+	}
+	return false
+}
+
+//Formats value as sql literal.
+//Times are cast to datetimeoffset, untyped string with offset can't be converted to datetime or smalldatetime.
+func sqlLiteral(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return fmt.Sprintf("N'%s'", quote(v)), nil
+	case time.Time:
+		return fmt.Sprintf("cast('%s' as datetimeoffset)", v.Format("2006-01-02T15:04:05.9999999-07:00")), nil
+	}
+	_, literal, err := go2SqlDataType(value)
+	return literal, err
+}
+
+//Executes stored procedure with table-valued params as sql batch.
+//Return status and output params are selected in the last result set, which is
+//removed from results.
+func (conn *Conn) execSpBatch(spName string, spParams []*spParam, params []interface{}) (*SpResult, error) {
+	sql, inserts, outputs, err := spBatchSql(spName, spParams, params)
+	if err != nil {
+		return nil, err
+	}
+	results, err := conn.Exec(sql)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 || len(results[len(results)-1].Rows) != 1 {
+		return nil, errors.New("missing return status")
+	}
+	last := results[len(results)-1]
+	result := NewSpResult()
+	result.results = skipInsertResults(results[:len(results)-1], inserts)
+	if err := convertAssign(&result.status, last.Rows[0][0]); err != nil {
+		return nil, err
+	}
+	result.outputParams = make([]*SpOutputParam, outputs)
+	for i := 0; i < outputs; i++ {
+		result.outputParams[i] = &SpOutputParam{Name: last.Columns[i+1].Name, Value: last.Rows[0][i+1]}
+	}
+	return result, nil
+}
+
+//Returns sql batch which calls stored procedure, number of TVP inserts and number of output params.
+//Params which are not given or are spDefault are left out, so the procedure uses default values.
+func spBatchSql(spName string, spParams []*spParam, params []interface{}) (string, int, int, error) {
+	batch := ""
+	inserts := 0
+	args := make([]string, 0, len(spParams))
+	outputs := make([]string, 0)
+	for i, spParam := range spParams {
+		omitted := i >= len(params) || isSpDefault(params[i])
+		var param interface{}
+		if !omitted {
+			param = params[i]
+			if named, ok := param.(sql.NamedArg); ok {
+				param = named.Value
+			}
+		}
+		variable := fmt.Sprintf("@p%d", i+1)
+		switch {
+		case spParam.IsOutput:
+			literal, err := sqlLiteral(param)
+			if err != nil {
+				return "", 0, 0, err
+			}
+			batch += fmt.Sprintf("declare %s %s = %s\n", variable, spParam.declaration(), literal)
+			args = append(args, fmt.Sprintf("%s=%s output", spParam.Name, variable))
+			outputs = append(outputs, fmt.Sprintf("%s [%s]", variable, spParam.Name))
+		case omitted:
+			//use default value
+		default:
+			if tvp, ok := param.(TVP); ok {
+				typeName := tvp.TypeName
+				if typeName == "" {
+					typeName = spParam.TypeName
+				}
+				declare, err := tvp.declare(variable, typeName)
+				if err != nil {
+					return "", 0, 0, err
+				}
+				batch += declare
+				inserts += tvp.inserts()
+				args = append(args, fmt.Sprintf("%s=%s", spParam.Name, variable))
+				continue
+			}
+			literal, err := sqlLiteral(param)
+			if err != nil {
+				return "", 0, 0, err
+			}
+			args = append(args, fmt.Sprintf("%s=%s", spParam.Name, literal))
+		}
+	}
+	batch += "declare @return_status int\n"
+	batch += fmt.Sprintf("exec @return_status = %s %s\n", quoteName(spName), strings.Join(args, ", "))
+	batch += fmt.Sprintf("select %s", strings.Join(append([]string{"@return_status [@return_status]"}, outputs...), ", "))
+	return batch, inserts, len(outputs), nil
+}
+
+//Sql type declaration of the param, used for output params variables.
+func (p *spParam) declaration() string {
+	if p.IsUserType {
+		return p.TypeName
+	}
+	size := func(length int) string {
+		if p.MaxLength == -1 {
+			return fmt.Sprintf("%s(max)", p.TypeName)
+		}
+		return fmt.Sprintf("%s(%d)", p.TypeName, length)
+	}
+	switch p.TypeName {
+	case "varchar", "char", "varbinary", "binary":
+		return size(int(p.MaxLength))
+	case "nvarchar", "nchar":
+		return size(int(p.MaxLength) / 2)
+	case "decimal", "numeric":
+		return fmt.Sprintf("%s(%d, %d)", p.TypeName, p.Precision, p.Scale)
+	case "datetime2", "time", "datetimeoffset":
+		return fmt.Sprintf("%s(%d)", p.TypeName, p.Scale)
+	}
+	return p.TypeName
+}
@@ -0,0 +1,209 @@
+package freetds
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTVP(t *testing.T) {
+	type row struct {
+		Id   int
+		Name string
+		note string
+	}
+	tvp, err := NewTVP("dbo.Rows", []row{{1, "one", ""}, {2, "two", ""}})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]interface{}{{1, "one"}, {2, "two"}}, tvp.Rows)
+
+	tvp, err = NewTVP("dbo.IdList", []int{1, 2})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]interface{}{{1}, {2}}, tvp.Rows)
+
+	_, err = NewTVP("dbo.IdList", 1)
+	assert.NotNil(t, err)
+}
+
+func TestTVPDeclare(t *testing.T) {
+	tvp := TVP{Columns: []string{"id", "name"}, Rows: [][]interface{}{{1, "o'ne"}, {2, nil}}}
+	sql, err := tvp.declare("@t", "dbo.Rows")
+	assert.Nil(t, err)
+	assert.Equal(t, "declare @t [dbo].[Rows]\ninsert into @t ([id], [name]) values\n(1, N'o''ne'),\n(2, null)\n", sql)
+
+	_, err = tvp.declare("@t", "")
+	assert.NotNil(t, err)
+
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 600000000, time.FixedZone("", 3600))
+	tvp = TVP{Rows: [][]interface{}{{tm}}}
+	sql, err = tvp.declare("@t", "dbo.Times")
+	assert.Nil(t, err)
+	assert.Equal(t, "declare @t [dbo].[Times]\ninsert into @t values\n(cast('2020-01-02T03:04:05.6+01:00' as datetimeoffset))\n", sql)
+
+	rows := make([][]interface{}, 2500)
+	for i := range rows {
+		rows[i] = []interface{}{i}
+	}
+	tvp = TVP{Rows: rows}
+	sql, err = tvp.declare("@t", "dbo.IdList")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, strings.Count(sql, "insert into"))
+	assert.Equal(t, 3, tvp.inserts())
+}
+
+func TestExecuteSqlStatementWithTVP(t *testing.T) {
+	conn := &Conn{credentials: credentials{}}
+	tvp := TVP{TypeName: "dbo.IdList", Rows: [][]interface{}{{1}}}
+	sql, err := conn.executeSqlStatement("select * from ? where id > ?", false, tvp, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "declare @tvp1 [dbo].[IdList]\ninsert into @tvp1 values\n(1)\n"+
+		"exec sp_executesql N'select * from @p1 where id > @p2', N'@p1 [dbo].[IdList] readonly, @p2 int', @p1=@tvp1, @p2=0", sql)
+}
+
+func TestSpParamDeclaration(t *testing.T) {
+	assert.Equal(t, "nvarchar(10)", (&spParam{TypeName: "nvarchar", MaxLength: 20}).declaration())
+	assert.Equal(t, "varchar(max)", (&spParam{TypeName: "varchar", MaxLength: -1}).declaration())
+	assert.Equal(t, "decimal(10, 2)", (&spParam{TypeName: "decimal", Precision: 10, Scale: 2}).declaration())
+	assert.Equal(t, "int", (&spParam{TypeName: "int", MaxLength: 4}).declaration())
+	assert.Equal(t, "[dbo].[Money]", (&spParam{TypeName: "[dbo].[Money]", IsUserType: true}).declaration())
+}
+
+func TestSpBatchSql(t *testing.T) {
+	spParams := []*spParam{
+		{Name: "@ids", TypeName: "test_id_list", IsUserType: true},
+		{Name: "@name", TypeName: "nvarchar", MaxLength: 40},
+		{Name: "@since", TypeName: "int", MaxLength: 4},
+		{Name: "@cnt", TypeName: "int", MaxLength: 4, IsOutput: true},
+	}
+	tvp := TVP{Rows: [][]interface{}{{1, "one"}}}
+	//@name skipped by database/sql named args, @since and @cnt not given
+	sql, inserts, outputs, err := spBatchSql("test_sp_tvp", spParams, []interface{}{tvp, spDefault{}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, inserts)
+	assert.Equal(t, 1, outputs)
+	assert.Equal(t, "declare @p1 [test_id_list]\ninsert into @p1 values\n(1, N'one')\n"+
+		"declare @p4 int = null\n"+
+		"declare @return_status int\n"+
+		"exec @return_status = [test_sp_tvp] @ids=@p1, @cnt=@p4 output\n"+
+		"select @return_status [@return_status], @p4 [@cnt]", sql)
+
+	sql, _, _, err = spBatchSql("test_sp_tvp", spParams, []interface{}{tvp, "one", spDefault{}, spDefault{}})
+	assert.Nil(t, err)
+	assert.Contains(t, sql, "exec @return_status = [test_sp_tvp] @ids=@p1, @name=N'one', @cnt=@p4 output\n")
+}
+
+func TestSpBatchSqlQuotesNames(t *testing.T) {
+	spParams := []*spParam{{Name: "@ids", TypeName: "test_id_list", IsUserType: true}}
+	//TVP given as named arg
+	named := sql.NamedArg{Name: "ids", Value: TVP{TypeName: "dbo.IdList]; drop table t --", Rows: [][]interface{}{{1}}}}
+	assert.True(t, hasTVP([]interface{}{named}))
+	batch, _, _, err := spBatchSql("dbo.sp; drop table t", spParams, []interface{}{named})
+	assert.Nil(t, err)
+	assert.Equal(t, "declare @p1 [dbo].[IdList]]; drop table t --]\ninsert into @p1 values\n(1)\n"+
+		"declare @return_status int\n"+
+		"exec @return_status = [dbo].[sp; drop table t] @ids=@p1\n"+
+		"select @return_status [@return_status]", batch)
+}
+
+func TestQuoteName(t *testing.T) {
+	assert.Equal(t, "", quoteName(""))
+	assert.Equal(t, "[dbo].[IdList]", quoteName("dbo.IdList"))
+	assert.Equal(t, "[dbo].[IdList]", quoteName("[dbo].[IdList]"))
+	assert.Equal(t, "[dbo].[Id.List]", quoteName(`dbo."Id.List"`))
+	assert.Equal(t, "[my db]..[a]]b]", quoteName("[my db]..[a]]b]"))
+	assert.Equal(t, "[#temp]", quoteName(" #temp "))
+	assert.Equal(t, "[x]]; drop table t]", quoteName("x]; drop table t"))
+}
+
+func TestExecSpWithTVP(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	if conn.sybaseMode() || conn.sybaseMode125() {
+		t.Skip("table-valued params do not exist in Sybase")
+	}
+	_, err := conn.Exec(`
+	if exists(select * from sys.procedures where name = 'test_sp_tvp')
+	  drop procedure test_sp_tvp
+	if exists(select * from sys.types where name = 'test_id_list')
+	  drop type test_id_list`)
+	assert.Nil(t, err)
+	_, err = conn.Exec("create type test_id_list as table (id int, name nvarchar(20))")
+	assert.Nil(t, err)
+	err = createProcedure(conn, "test_sp_tvp", `
+    (@ids test_id_list readonly, @cnt int output) as
+    select id, name from @ids order by id
+    select @cnt = count(*) from @ids
+    return 7`)
+	assert.Nil(t, err)
+
+	type row struct {
+		Id   int
+		Name string
+	}
+	tvp, _ := NewTVP("", []row{{1, "išo"}, {2, "medo"}})
+	rst, err := conn.ExecSp("test_sp_tvp", tvp)
+	assert.Nil(t, err)
+	assert.Equal(t, 7, rst.Status())
+	var cnt int
+	assert.Nil(t, rst.ParamScan(&cnt))
+	assert.Equal(t, 2, cnt)
+	rst.Next()
+	var id int
+	var name string
+	assert.Nil(t, rst.Scan(&id, &name))
+	assert.Equal(t, 1, id)
+	assert.Equal(t, "išo", name)
+
+	tvp.TypeName = "test_id_list"
+	results, err := conn.ExecuteSql("select count(*) from ? where id > ?", tvp, 1)
+	assert.Nil(t, err)
+	var n int
+	results[0].Next()
+	assert.Nil(t, results[0].Scan(&n))
+	assert.Equal(t, 1, n)
+}
+
+func TestExecSpWithTVPDatetimeColumn(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	if conn.sybaseMode() || conn.sybaseMode125() {
+		t.Skip("table-valued params do not exist in Sybase")
+	}
+	_, err := conn.Exec(`
+	if exists(select * from sys.procedures where name = 'test_sp_tvp_datetime')
+	  drop procedure test_sp_tvp_datetime
+	if exists(select * from sys.types where name = 'test_datetime_list')
+	  drop type test_datetime_list`)
+	assert.Nil(t, err)
+	_, err = conn.Exec("create type test_datetime_list as table (created datetime, day smalldatetime)")
+	assert.Nil(t, err)
+	err = createProcedure(conn, "test_sp_tvp_datetime", `
+    (@times test_datetime_list readonly, @since datetime) as
+    select created, day from @times where created >= @since`)
+	assert.Nil(t, err)
+
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	type row struct {
+		Created time.Time
+		Day     time.Time
+	}
+	tvp, _ := NewTVP("", []row{{tm, tm}})
+	rst, err := conn.ExecSp("test_sp_tvp_datetime", tvp, tm)
+	assert.Nil(t, err)
+	if rst == nil {
+		return
+	}
+	assert.True(t, rst.Next())
+	var created, day time.Time
+	assert.Nil(t, rst.Scan(&created, &day))
+	assert.Equal(t, "2020-01-02T03:04:05", created.Format("2006-01-02T15:04:05"))
+	assert.Equal(t, "2020-01-02T03:04", day.Format("2006-01-02T15:04"))
+}
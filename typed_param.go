@@ -0,0 +1,157 @@
+package freetds
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+//TypedParam is a param with explicit sql type declaration.
+//By default strings are declared as nvarchar and floats as real, typed params
+//control exactly how the param is declared and encoded.
+//Create it with VarChar, NVarChar, Char, DecimalParam, Float, DateTime2, VarBinary or Binary.
+//
+//Example:
+//  rst, err := conn.ExecuteSql("select * from authors where au_id = ?", freetds.VarChar("172-32-1176", 11))
+type TypedParam struct {
+	//Sql type of the param, e.g. varchar(10).
+	Type string
+	//Value of the param, used when sent to stored procedure through rpc.
+	Value interface{}
+	//sql literal of the value
+	literal string
+	err     error
+}
+
+//Max size of the char and binary types, and of the nchar types.
+const (
+	maxCharSize  = 8000
+	maxNCharSize = 4000
+)
+
+//size of the char type, max when size is not in the allowed range
+func charSize(size, max int) string {
+	if size <= 0 || size > max {
+		return "max"
+	}
+	return strconv.Itoa(size)
+}
+
+func checkLength(p TypedParam, length, size int) TypedParam {
+	if size > 0 && length > size {
+		p.err = fmt.Errorf("value of length %d doesn't fit into %s", length, p.Type)
+	}
+	return p
+}
+
+//VarChar param, declared as varchar(size) or varchar(max) when size is 0.
+//Use it to compare with varchar columns without implicit conversion to nvarchar.
+func VarChar(s string, size int) TypedParam {
+	p := TypedParam{
+		Type:    fmt.Sprintf("varchar(%s)", charSize(size, maxCharSize)),
+		Value:   s,
+		literal: fmt.Sprintf("'%s'", quote(s)),
+	}
+	return checkLength(p, utf8.RuneCountInString(s), size)
+}
+
+//NVarChar param, declared as nvarchar(size) or nvarchar(max) when size is 0.
+func NVarChar(s string, size int) TypedParam {
+	p := TypedParam{
+		Type:    fmt.Sprintf("nvarchar(%s)", charSize(size, maxNCharSize)),
+		Value:   s,
+		literal: fmt.Sprintf("N'%s'", quote(s)),
+	}
+	return checkLength(p, len(utf16.Encode([]rune(s))), size)
+}
+
+//Char param, declared as char(size).
+func Char(s string, size int) TypedParam {
+	p := TypedParam{
+		Type:    fmt.Sprintf("char(%d)", size),
+		Value:   s,
+		literal: fmt.Sprintf("'%s'", quote(s)),
+	}
+	if size <= 0 || size > maxCharSize {
+		p.err = fmt.Errorf("invalid char size %d", size)
+		return p
+	}
+	return checkLength(p, utf8.RuneCountInString(s), size)
+}
+
+//DecimalParam is decimal param, declared as decimal(precision, scale).
+//Value is anything accepted as Decimal: Decimal, *big.Rat, float, integer or string.
+func DecimalParam(value interface{}, precision, scale int) TypedParam {
+	p := TypedParam{Type: fmt.Sprintf("decimal(%d, %d)", precision, scale)}
+	if precision < 1 || precision > decimalMaxPrecision || scale < 0 || scale > precision {
+		p.err = fmt.Errorf("invalid decimal precision %d and scale %d", precision, scale)
+		return p
+	}
+	d, err := toDecimal(value)
+	if err != nil {
+		p.err = err
+		return p
+	}
+	d = d.Rescale(uint8(scale))
+	if len(new(big.Int).Abs(d.Unscaled()).String()) > precision {
+		p.err = fmt.Errorf("value %s doesn't fit into %s", d, p.Type)
+		return p
+	}
+	p.Value = d
+	p.literal = d.String()
+	return p
+}
+
+//Float param, declared as float which keeps float64 precision.
+func Float(f float64) TypedParam {
+	return TypedParam{
+		Type:    "float",
+		Value:   f,
+		literal: strconv.FormatFloat(f, 'g', -1, 64),
+	}
+}
+
+//DateTime2 param, declared as datetime2(scale).
+//Scale is number of fractional second digits, from 0 to 7.
+//Time is converted to local timezone, the same as time params of ExecSp.
+func DateTime2(t time.Time, scale int) TypedParam {
+	p := TypedParam{Type: fmt.Sprintf("datetime2(%d)", scale), Value: t}
+	if scale < 0 || scale > 7 {
+		p.err = fmt.Errorf("invalid datetime2 scale %d", scale)
+		return p
+	}
+	layout := "2006-01-02T15:04:05"
+	if scale > 0 {
+		layout += "." + strings.Repeat("0", scale)
+	}
+	p.literal = fmt.Sprintf("'%s'", t.In(time.Local).Format(layout))
+	return p
+}
+
+//VarBinary param, declared as varbinary(size) or varbinary(max) when size is 0.
+func VarBinary(b []byte, size int) TypedParam {
+	p := TypedParam{
+		Type:    fmt.Sprintf("varbinary(%s)", charSize(size, maxCharSize)),
+		Value:   b,
+		literal: fmt.Sprintf("0x%x", b),
+	}
+	return checkLength(p, len(b), size)
+}
+
+//Binary param, declared as binary(size).
+func Binary(b []byte, size int) TypedParam {
+	p := TypedParam{
+		Type:    fmt.Sprintf("binary(%d)", size),
+		Value:   b,
+		literal: fmt.Sprintf("0x%x", b),
+	}
+	if size <= 0 || size > maxCharSize {
+		p.err = fmt.Errorf("invalid binary size %d", size)
+		return p
+	}
+	return checkLength(p, len(b), size)
+}
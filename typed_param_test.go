@@ -0,0 +1,92 @@
+package freetds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedParamDeclaration(t *testing.T) {
+	var checker = func(value interface{}, sqlType string, sqlFormatedValue string) {
+		actualSqlType, actualSqlFormatedValue, err := go2SqlDataType(value)
+		assert.Nil(t, err)
+		assert.Equal(t, sqlType, actualSqlType)
+		assert.Equal(t, sqlFormatedValue, actualSqlFormatedValue)
+	}
+	checker(VarChar("pero's", 10), "varchar(10)", "'pero''s'")
+	checker(VarChar("pero", 0), "varchar(max)", "'pero'")
+	checker(NVarChar("žaba", 4), "nvarchar(4)", "N'žaba'")
+	checker(NVarChar("žaba", 0), "nvarchar(max)", "N'žaba'")
+	checker(Char("ab", 2), "char(2)", "'ab'")
+	checker(DecimalParam("12.345", 10, 2), "decimal(10, 2)", "12.35")
+	checker(DecimalParam(0.5, 1, 1), "decimal(1, 1)", "0.5")
+	checker(DecimalParam(12, 5, 2), "decimal(5, 2)", "12.00")
+	checker(Float(0.1), "float", "0.1")
+	checker(Float(1234567.123456789), "float", "1.234567123456789e+06")
+	tm := time.Date(2017, 6, 1, 12, 30, 15, 123456789, time.Local)
+	checker(DateTime2(tm, 3), "datetime2(3)", "'2017-06-01T12:30:15.123'")
+	checker(DateTime2(tm, 0), "datetime2(0)", "'2017-06-01T12:30:15'")
+	//converted to local timezone
+	checker(DateTime2(tm.In(time.FixedZone("", -11*3600)), 0), "datetime2(0)", "'2017-06-01T12:30:15'")
+	checker(Binary([]byte{1, 2}, 4), "binary(4)", "0x0102")
+}
+
+func TestTypedParamErrors(t *testing.T) {
+	for _, p := range []TypedParam{
+		VarChar("pero", 3),
+		NVarChar("žaba", 3),
+		Char("ab", 0),
+		DecimalParam("123.4", 3, 1),
+		DecimalParam("1", 39, 0),
+		DecimalParam("pero", 10, 2),
+		DateTime2(time.Now(), 8),
+		Binary([]byte{1, 2, 3}, 2),
+	} {
+		_, _, err := go2SqlDataType(p)
+		assert.NotNil(t, err, p.Type)
+		_, _, err = typeToSqlBuf(SYBVARCHAR, p, true)
+		assert.NotNil(t, err, p.Type)
+	}
+}
+
+func TestTypedParamToSqlBuf(t *testing.T) {
+	data, _, err := typeToSqlBuf(SYBVARCHAR, VarChar("pero", 10), true)
+	assert.Nil(t, err)
+	expected, _, _ := typeToSqlBuf(SYBVARCHAR, "pero", true)
+	assert.Equal(t, expected, data)
+
+	data, _, err = typeToSqlBuf(SYBFLT8, Float(0.1), true)
+	assert.Nil(t, err)
+	expected, _, _ = typeToSqlBuf(SYBFLT8, 0.1, true)
+	assert.Equal(t, expected, data)
+}
+
+func TestExecuteSqlTypedParams(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	results, err := conn.ExecuteSql("select sql_variant_property(?, 'BaseType'), ?, ?",
+		VarChar("172-32-1176", 11), Float(0.1), DecimalParam("1.005", 10, 3))
+	assert.Nil(t, err)
+	assert.Equal(t, "varchar", results[0].Rows[0][0])
+	assert.Equal(t, 0.1, results[0].Rows[0][1])
+	assert.Equal(t, "1.005", results[0].Rows[0][2].(Decimal).String())
+
+	results, err = conn.ExecuteSql("select au_lname from authors where au_id = ?", VarChar("172-32-1176", 11))
+	assert.Nil(t, err)
+	assert.Equal(t, "White", results[0].Rows[0][0])
+
+	rst, err := conn.ExecSp("sp_help", VarChar("authors", 0))
+	assert.Nil(t, err)
+	assert.True(t, rst.HasResults())
+
+	db, _, _ := open(t)
+	defer db.Close()
+	var lname string
+	err = db.QueryRow("select au_lname from authors where au_id = ?", VarChar("172-32-1176", 11)).Scan(&lname)
+	assert.Nil(t, err)
+	assert.Equal(t, "White", lname)
+}
@@ -0,0 +1,102 @@
+package freetds
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+//UniqueIdentifier is the Sql Server uniqueidentifier (GUID) value.
+//
+//Bytes are in the canonical order, same as in the string form:
+//  B5A0E32D-3F48-4CC2-A44B-74753D9CACF8
+//Sql Server stores first three groups little-endian, conversion is done when
+//the value is read from or sent to the server.
+//
+//Uniqueidentifier columns and output params are read as UniqueIdentifier.
+//UniqueIdentifier can be used as ExecuteSql or ExecSp param, and as Scan destination.
+type UniqueIdentifier [16]byte
+
+//ParseUniqueIdentifier parses string like "B5A0E32D-3F48-4CC2-A44B-74753D9CACF8",
+//optionally enclosed in braces.
+func ParseUniqueIdentifier(s string) (UniqueIdentifier, error) {
+	var u UniqueIdentifier
+	str := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "{"), "}")
+	if len(str) != 36 || str[8] != '-' || str[13] != '-' || str[18] != '-' || str[23] != '-' {
+		return u, fmt.Errorf("invalid uniqueidentifier %q", s)
+	}
+	str = strings.Replace(str, "-", "", -1)
+	if _, err := hex.Decode(u[:], []byte(str)); err != nil {
+		return u, fmt.Errorf("invalid uniqueidentifier %q", s)
+	}
+	return u, nil
+}
+
+//Uppercase string form, same as Sql Server returns when uniqueidentifier is casted to varchar.
+func (u UniqueIdentifier) String() string {
+	s := strings.ToUpper(hex.EncodeToString(u[:]))
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+//Implements sql.Scanner.
+//Accepts UniqueIdentifier, string form and 16 bytes in the Sql Server byte order.
+func (u *UniqueIdentifier) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case UniqueIdentifier:
+		*u = v
+		return nil
+	case string:
+		uv, err := ParseUniqueIdentifier(v)
+		if err == nil {
+			*u = uv
+		}
+		return err
+	case []byte:
+		if len(v) == 16 {
+			*u = uniqueIdentifierFromSqlBuf(v)
+			return nil
+		}
+		return u.Scan(string(v))
+	}
+	return fmt.Errorf("can't convert %T to UniqueIdentifier", src)
+}
+
+//Implements driver.Valuer.
+func (u UniqueIdentifier) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+//Converts UniqueIdentifier, [16]byte or string to uniqueidentifier.
+func toUniqueIdentifier(value interface{}) (UniqueIdentifier, error) {
+	switch v := value.(type) {
+	case UniqueIdentifier:
+		return v, nil
+	case [16]byte:
+		return UniqueIdentifier(v), nil
+	case string:
+		return ParseUniqueIdentifier(v)
+	}
+	return UniqueIdentifier{}, fmt.Errorf("Could not convert %T to uniqueidentifier.", value)
+}
+
+//Swaps bytes of the first three groups, same function converts in both directions.
+func swapUniqueIdentifier(b []byte) UniqueIdentifier {
+	var u UniqueIdentifier
+	copy(u[:], b)
+	u[0], u[1], u[2], u[3] = u[3], u[2], u[1], u[0]
+	u[4], u[5] = u[5], u[4]
+	u[6], u[7] = u[7], u[6]
+	return u
+}
+
+//Decodes 16 bytes in the Sql Server byte order.
+func uniqueIdentifierFromSqlBuf(data []byte) UniqueIdentifier {
+	return swapUniqueIdentifier(data)
+}
+
+//Encodes uniqueidentifier in the Sql Server byte order.
+func (u UniqueIdentifier) toSqlBuf() []byte {
+	s := swapUniqueIdentifier(u[:])
+	return s[:]
+}
@@ -0,0 +1,76 @@
+package freetds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUniqueIdentifier(t *testing.T) {
+	u, err := ParseUniqueIdentifier("b5a0e32d-3f48-4cc2-a44b-74753d9cacf8")
+	assert.Nil(t, err)
+	assert.Equal(t, "B5A0E32D-3F48-4CC2-A44B-74753D9CACF8", u.String())
+	assert.Equal(t, byte(0xB5), u[0])
+
+	u2, err := ParseUniqueIdentifier("{B5A0E32D-3F48-4CC2-A44B-74753D9CACF8}")
+	assert.Nil(t, err)
+	assert.Equal(t, u, u2)
+
+	for _, s := range []string{"", "B5A0E32D3F484CC2A44B74753D9CACF8", "X5A0E32D-3F48-4CC2-A44B-74753D9CACF8"} {
+		_, err = ParseUniqueIdentifier(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestUniqueIdentifierSqlBuf(t *testing.T) {
+	u, _ := ParseUniqueIdentifier("00112233-4455-6677-8899-AABBCCDDEEFF")
+	data, datalen, err := typeToSqlBuf(SYBUNIQUE, u, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 16, datalen)
+	assert.Equal(t, []byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66,
+		0x88, 0x99, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF}, data)
+	assert.Equal(t, u, sqlBufToType(SYBUNIQUE, append(data, 0)))
+
+	data2, _, err := typeToSqlBuf(SYBUNIQUE, u.String(), true)
+	assert.Nil(t, err)
+	assert.Equal(t, data, data2)
+}
+
+func TestUniqueIdentifierConvertAssign(t *testing.T) {
+	u, _ := ParseUniqueIdentifier("B5A0E32D-3F48-4CC2-A44B-74753D9CACF8")
+	var s string
+	assert.Nil(t, convertAssign(&s, u))
+	assert.Equal(t, u.String(), s)
+	var u2 UniqueIdentifier
+	assert.Nil(t, convertAssign(&u2, u))
+	assert.Equal(t, u, u2)
+	var u3 UniqueIdentifier
+	assert.Nil(t, convertAssign(&u3, s))
+	assert.Equal(t, u, u3)
+
+	typ, value, err := go2SqlDataType(u)
+	assert.Nil(t, err)
+	assert.Equal(t, "uniqueidentifier", typ)
+	assert.Equal(t, "'B5A0E32D-3F48-4CC2-A44B-74753D9CACF8'", value)
+}
+
+func TestUniqueIdentifierRoundTrip(t *testing.T) {
+	conn := ConnectToTestDb(t)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	if conn.sybaseMode() || conn.sybaseMode125() {
+		t.Skip("uniqueidentifier does not exist in Sybase")
+	}
+
+	u, _ := ParseUniqueIdentifier("B5A0E32D-3F48-4CC2-A44B-74753D9CACF8")
+	results, err := conn.ExecuteSql("select ?, cast(? as varchar(36))", u, u)
+	assert.Nil(t, err)
+	results[0].Next()
+	var u2 UniqueIdentifier
+	var s string
+	assert.Nil(t, results[0].Scan(&u2, &s))
+	assert.Equal(t, u, u2)
+	assert.Equal(t, u.String(), s)
+}